@@ -3,7 +3,9 @@ package recorder
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 )
 
 // RecordType categorizes the kind of payload being stored.
@@ -14,6 +16,9 @@ const (
 	RecordTypeResponse RecordType = "response"
 	RecordTypeError    RecordType = "error"
 	RecordTypeMetrics  RecordType = "metrics"
+	// RecordTypeScrubReport holds the ScrubReport of another record, see
+	// ScrubberReportRecord.
+	RecordTypeScrubReport RecordType = "scrub_report"
 )
 
 // Record represents a single item to persist in a storage backend.
@@ -23,6 +28,15 @@ type Record struct {
 	RequestID string
 	Payload   []byte
 	Tags      map[string]string
+	Timestamp time.Time
+	// ContentType is set for records created by RecordValue, RecordError and
+	// RecordMetrics.
+	ContentType string
+
+	// valueScrubbed marks payloads that were scrubbed before serialization.
+	valueScrubbed bool
+	// scrubReport lists what the scrubber changed when scrub reports are enabled.
+	scrubReport *ScrubReport
 }
 
 // Storage abstracts the persistence layer used by Recorder implementations.
@@ -38,25 +52,58 @@ func New(storage Storage, opts ...RecorderOption) Recorder {
 		panic("recorder: storage must not be nil")
 	}
 
-	var cfg recorderOptions
-	for _, opt := range opts {
-		if opt == nil {
-			continue
+	cfg := newRecorderOptions(opts...)
+	for i := len(cfg.middlewares) - 1; i >= 0; i-- {
+		if wrapped := cfg.middlewares[i](storage); wrapped != nil {
+			storage = wrapped
 		}
-		opt(&cfg)
+	}
+	logger, metrics := cfg.logger, cfg.metrics
+	if logger == nil {
+		logger = NewDefaultLogger()
+	}
+	if metrics == nil {
+		metrics = NewMetrics()
 	}
 
-	return &baseRecorder{
-		storage:         storage,
-		payloadScrubber: cfg.payloadScrubber,
-		tagScrubber:     cfg.tagScrubber,
+	serializer := cfg.serializer
+	if serializer == nil {
+		serializer = JSONSerializer()
+	}
+
+	r := &baseRecorder{
+		storage:           storage,
+		hooks:             cfg.hooks,
+		enrichment:        cfg.enrichment,
+		serializer:        serializer,
+		valueScrubber:     cfg.valueScrubber,
+		errorStacks:       cfg.errorStacks,
+		classifiers:       cfg.errorClassifiers,
+		metricsForwarding: cfg.metricsForwarding,
+		logger:            logger,
+		metrics:           metrics,
+	}
+	if cfg.payloadScrubber != nil || cfg.tagScrubber != nil {
+		r.hooks = append([]Hook{r.scrubHook(cfg.payloadScrubber, cfg.tagScrubber, cfg.scrubAudit)}, cfg.hooks...)
 	}
+	if cfg.correlation != nil {
+		r.correlator = newCorrelator(*cfg.correlation, metrics)
+	}
+	return r
 }
 
 type baseRecorder struct {
-	storage         Storage
-	payloadScrubber PayloadScrubFunc
-	tagScrubber     TagScrubFunc
+	storage           Storage
+	hooks             []Hook
+	enrichment        tagEnrichment
+	serializer        Serializer
+	valueScrubber     valueScrubFunc
+	errorStacks       bool
+	classifiers       []ErrorClassifier
+	logger            Logger
+	metrics           Metrics
+	metricsForwarding *metricsForwardConfig
+	correlator        *correlator
 }
 
 func (r *baseRecorder) RecordRequest(ctx context.Context, primaryID *string, requestID string, request []byte, tags map[string]string) error {
@@ -67,22 +114,19 @@ func (r *baseRecorder) RecordRequest(ctx context.Context, primaryID *string, req
 		return fmt.Errorf("request cannot be nil or empty")
 	}
 
-	sanitizedPayload, err := r.scrubPayload(RecordTypeRequest, request)
-	if err != nil {
-		return fmt.Errorf("scrub request payload: %w", err)
-	}
-	sanitizedTags, err := r.prepareTags(RecordTypeRequest, tags)
-	if err != nil {
-		return fmt.Errorf("scrub request tags: %w", err)
-	}
-
-	return r.storage.Save(ctx, Record{
+	record := Record{
 		Type:      RecordTypeRequest,
 		PrimaryID: primaryID,
 		RequestID: requestID,
-		Payload:   sanitizedPayload,
-		Tags:      sanitizedTags,
-	})
+		Payload:   request,
+		Tags:      r.prepareTags(ctx, tags),
+		Timestamp: time.Now(),
+	}
+	if saved, err := r.save(ctx, &record); err != nil || !saved {
+		return err
+	}
+	r.correlate(ctx, record)
+	return nil
 }
 
 func (r *baseRecorder) RecordResponse(ctx context.Context, primaryID *string, requestID string, response []byte, tags map[string]string) error {
@@ -93,22 +137,19 @@ func (r *baseRecorder) RecordResponse(ctx context.Context, primaryID *string, re
 		return fmt.Errorf("response cannot be nil or empty")
 	}
 
-	sanitizedPayload, err := r.scrubPayload(RecordTypeResponse, response)
-	if err != nil {
-		return fmt.Errorf("scrub response payload: %w", err)
-	}
-	sanitizedTags, err := r.prepareTags(RecordTypeResponse, tags)
-	if err != nil {
-		return fmt.Errorf("scrub response tags: %w", err)
-	}
-
-	return r.storage.Save(ctx, Record{
+	record := Record{
 		Type:      RecordTypeResponse,
 		PrimaryID: primaryID,
 		RequestID: requestID,
-		Payload:   sanitizedPayload,
-		Tags:      sanitizedTags,
-	})
+		Payload:   response,
+		Tags:      r.prepareTags(ctx, tags),
+		Timestamp: time.Now(),
+	}
+	if saved, err := r.save(ctx, &record); err != nil || !saved {
+		return err
+	}
+	r.correlate(ctx, record)
+	return nil
 }
 
 func (r *baseRecorder) RecordError(ctx context.Context, id *string, requestID string, err error, tags map[string]string) error {
@@ -119,22 +160,38 @@ func (r *baseRecorder) RecordError(ctx context.Context, id *string, requestID st
 		return fmt.Errorf("requestID cannot be empty")
 	}
 
-	sanitizedPayload, scrubErr := r.scrubPayload(RecordTypeError, []byte(err.Error()))
-	if scrubErr != nil {
-		return fmt.Errorf("scrub error payload: %w", scrubErr)
+	errRecord := NewErrorRecord(err)
+	errRecord.ErrorClassification = ClassifyError(err, r.classifiers...)
+	if r.errorStacks {
+		errRecord.Stack = callerStack(1)
 	}
-	sanitizedTags, scrubErr := r.prepareTags(RecordTypeError, tags)
-	if scrubErr != nil {
-		return fmt.Errorf("scrub error tags: %w", scrubErr)
+	payload, marshalErr := json.Marshal(errRecord)
+	if marshalErr != nil {
+		return fmt.Errorf("cannot marshal error: %w", marshalErr)
 	}
 
-	return r.storage.Save(ctx, Record{
-		Type:      RecordTypeError,
-		PrimaryID: id,
-		RequestID: requestID,
-		Payload:   sanitizedPayload,
-		Tags:      sanitizedTags,
-	})
+	recordTags := r.prepareTags(ctx, tags)
+	if recordTags == nil {
+		recordTags = make(map[string]string, 1)
+	}
+	if _, set := recordTags[ErrorClassTag]; !set {
+		recordTags[ErrorClassTag] = errRecord.Class
+	}
+
+	record := Record{
+		Type:        RecordTypeError,
+		PrimaryID:   id,
+		RequestID:   requestID,
+		Payload:     payload,
+		Tags:        recordTags,
+		Timestamp:   time.Now(),
+		ContentType: ContentTypeErrorRecord,
+	}
+	if saved, err := r.save(ctx, &record); err != nil || !saved {
+		return err
+	}
+	r.correlate(ctx, record)
+	return nil
 }
 
 func (r *baseRecorder) RecordMetrics(ctx context.Context, primaryID *string, requestID string, metrics map[string]string, tags map[string]string) error {
@@ -149,43 +206,113 @@ func (r *baseRecorder) RecordMetrics(ctx context.Context, primaryID *string, req
 	if err != nil {
 		return fmt.Errorf("cannot marshal metrics: %w", err)
 	}
-	sanitizedPayload, scrubErr := r.scrubPayload(RecordTypeMetrics, jsonData)
-	if scrubErr != nil {
-		return fmt.Errorf("scrub metrics payload: %w", scrubErr)
+
+	_, err = r.save(ctx, &Record{
+		Type:        RecordTypeMetrics,
+		PrimaryID:   primaryID,
+		RequestID:   requestID,
+		Payload:     jsonData,
+		Tags:        r.prepareTags(ctx, tags),
+		Timestamp:   time.Now(),
+		ContentType: ContentTypeJSON,
+	})
+	return err
+}
+
+func (r *baseRecorder) RecordValue(ctx context.Context, recordType RecordType, primaryID *string, requestID string, value any, tags map[string]string) error {
+	if requestID == "" {
+		return fmt.Errorf("requestID cannot be empty")
 	}
-	sanitizedTags, scrubErr := r.prepareTags(RecordTypeMetrics, tags)
-	if scrubErr != nil {
-		return fmt.Errorf("scrub metrics tags: %w", scrubErr)
+	if value == nil {
+		return fmt.Errorf("value cannot be nil")
 	}
 
-	return r.storage.Save(ctx, Record{
-		Type:      RecordTypeMetrics,
-		PrimaryID: primaryID,
-		RequestID: requestID,
-		Payload:   sanitizedPayload,
-		Tags:      sanitizedTags,
-	})
+	scrubbed := false
+	var report *ScrubReport
+	if r.valueScrubber != nil {
+		sanitized, valueReport, err := r.valueScrubber(recordType, value)
+		if err != nil {
+			return fmt.Errorf("scrub %s value: %w", recordType, err)
+		}
+		value, report, scrubbed = sanitized, valueReport, true
+	}
+	payload, err := r.serializer.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("serialize %s value: %w", recordType, err)
+	}
+	if len(payload) == 0 {
+		return fmt.Errorf("%s value serialized to an empty payload", recordType)
+	}
+
+	contentType := r.serializer.ContentType()
+	recordTags := r.prepareTags(ctx, tags)
+	if recordTags == nil {
+		recordTags = make(map[string]string, 1)
+	}
+	recordTags[ContentTypeTag] = contentType
+
+	record := Record{
+		Type:          recordType,
+		PrimaryID:     primaryID,
+		RequestID:     requestID,
+		Payload:       payload,
+		Tags:          recordTags,
+		Timestamp:     time.Now(),
+		ContentType:   contentType,
+		valueScrubbed: scrubbed,
+		scrubReport:   report,
+	}
+	if saved, err := r.save(ctx, &record); err != nil || !saved {
+		return err
+	}
+	r.correlate(ctx, record)
+	return nil
 }
 
 func (r *baseRecorder) GetRequest(ctx context.Context, requestID string) ([]byte, error) {
 	if requestID == "" {
 		return nil, fmt.Errorf("requestID cannot be empty")
 	}
-	return r.storage.Load(ctx, RecordTypeRequest, requestID)
+	return r.load(ctx, RecordTypeRequest, requestID)
 }
 
 func (r *baseRecorder) GetResponse(ctx context.Context, requestID string) ([]byte, error) {
 	if requestID == "" {
 		return nil, fmt.Errorf("requestID cannot be empty")
 	}
-	return r.storage.Load(ctx, RecordTypeResponse, requestID)
+	return r.load(ctx, RecordTypeResponse, requestID)
+}
+
+func (r *baseRecorder) GetRequestInto(ctx context.Context, requestID string, dst any) error {
+	return r.loadInto(ctx, RecordTypeRequest, requestID, dst)
+}
+
+func (r *baseRecorder) GetResponseInto(ctx context.Context, requestID string, dst any) error {
+	return r.loadInto(ctx, RecordTypeResponse, requestID, dst)
+}
+
+func (r *baseRecorder) loadInto(ctx context.Context, recordType RecordType, requestID string, dst any) error {
+	if requestID == "" {
+		return fmt.Errorf("requestID cannot be empty")
+	}
+	if dst == nil {
+		return fmt.Errorf("destination cannot be nil")
+	}
+	data, err := r.load(ctx, recordType, requestID)
+	if err != nil {
+		return err
+	}
+	if err := r.serializer.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("deserialize %s: %w", recordType, err)
+	}
+	return nil
 }
 
 func (r *baseRecorder) FindByTag(ctx context.Context, tag string) ([]string, error) {
 	if tag == "" {
 		return nil, fmt.Errorf("tag cannot be empty")
 	}
-	return r.storage.FindByTag(ctx, tag)
+	return r.findByTag(ctx, tag)
 }
 
 func (r *baseRecorder) Async() AsyncRecorder {
@@ -255,19 +382,94 @@ func (ar *asyncRecorder) FindByTag(ctx context.Context, tag string) <-chan FindB
 	return resultChan
 }
 
-func (r *baseRecorder) scrubPayload(recordType RecordType, payload []byte) ([]byte, error) {
-	if r.payloadScrubber == nil || len(payload) == 0 {
-		return payload, nil
+// save, load and findByTag wrap the storage so every backend reports the same
+// recorder.storage.* counters and timings and hooks run around every call. The record
+// fields are put in the context so storage logs carry them. save reports false when a
+// hook skipped the record.
+func (r *baseRecorder) save(ctx context.Context, record *Record) (bool, error) {
+	err := r.beforeSave(ctx, record)
+	if errors.Is(err, ErrSkipRecord) {
+		return false, nil
+	}
+	if err == nil {
+		tags := map[string]string{"type": string(record.Type)}
+		start := time.Now()
+		err = r.storage.Save(contextWithRecord(ctx, record.Type, record.RequestID, record.PrimaryID), *record)
+		r.observe("save", tags, start, err)
 	}
-	return r.payloadScrubber(recordType, append([]byte(nil), payload...))
+	r.afterSave(ctx, *record, err)
+	return err == nil, err
 }
 
-func (r *baseRecorder) prepareTags(recordType RecordType, tags map[string]string) (map[string]string, error) {
-	cloned := cloneTags(tags)
-	if r.tagScrubber == nil || len(cloned) == 0 {
-		return cloned, nil
+func (r *baseRecorder) load(ctx context.Context, recordType RecordType, requestID string) ([]byte, error) {
+	tags := map[string]string{"type": string(recordType)}
+	start := time.Now()
+	data, err := r.storage.Load(contextWithRecord(ctx, recordType, requestID, nil), recordType, requestID)
+	r.observe("load", tags, start, err)
+	return r.afterLoad(ctx, recordType, requestID, data, err)
+}
+
+func (r *baseRecorder) findByTag(ctx context.Context, tag string) ([]string, error) {
+	start := time.Now()
+	results, err := r.storage.FindByTag(ctx, tag)
+	r.observe("find_by_tag", nil, start, err)
+	return results, err
+}
+
+func (r *baseRecorder) observe(operation string, tags map[string]string, start time.Time, err error) {
+	r.metrics.RecordTiming("recorder.storage."+operation+".duration", time.Since(start), tags)
+	if err != nil {
+		r.metrics.IncrementCounter("recorder.storage."+operation+".errors", tags)
+		return
+	}
+	r.metrics.IncrementCounter("recorder.storage."+operation+".success", tags)
+}
+
+// correlate opens an exchange for requests and closes it for responses and errors. The
+// derived metrics record is best effort: the response or error itself has already been
+// stored.
+func (r *baseRecorder) correlate(ctx context.Context, record Record) {
+	if r.correlator == nil {
+		return
+	}
+	var outcome string
+	switch record.Type {
+	case RecordTypeRequest:
+		r.correlator.request(record)
+		return
+	case RecordTypeResponse:
+		outcome = ExchangeOutcomeSuccess
+	case RecordTypeError:
+		outcome = ExchangeOutcomeError
+	default:
+		return
+	}
+	ex, ok := r.correlator.complete(record, outcome)
+	if !ok || !r.correlator.cfg.recordMetrics {
+		return
+	}
+	if err := r.RecordMetrics(ctx, ex.primaryID, ex.requestID, ex.metricsPayload(), ex.tags); err != nil {
+		r.logger.WithContext(ctx).Warn("failed to record exchange metrics", "request_id", ex.requestID, "error", err)
+	}
+}
+
+// prepareTags copies the caller tags and merges in the static and context-derived tags.
+// Scrubbing happens later in the hook chain, so enriched tags are scrubbed too.
+func (r *baseRecorder) prepareTags(ctx context.Context, tags map[string]string) map[string]string {
+	if !r.enrichment.enabled() {
+		return cloneTags(tags)
+	}
+	merged := r.enrichment.tags(ctx)
+	for k, v := range tags {
+		if _, exists := merged[k]; exists && r.enrichment.policy == TagConflictEnricherWins {
+			continue
+		}
+		merged[k] = v
+	}
+	if len(merged) == 0 {
+		return nil
 	}
-	return r.tagScrubber(recordType, cloned)
+	return merged
 }
 
 func cloneTags(tags map[string]string) map[string]string {
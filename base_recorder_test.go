@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"reflect"
 	"testing"
 )
@@ -419,3 +420,92 @@ func TestCloneTags(t *testing.T) {
 		t.Fatalf("expected clone to keep original value, got %s", clone["a"])
 	}
 }
+
+func TestRecorderInstrumentsStorageOperations(t *testing.T) {
+	ctx := context.Background()
+	metrics := NewMetrics()
+	saveErr := errors.New("save failed")
+	storage := stubStorage{
+		saveFn: func(_ context.Context, record Record) error {
+			if record.RequestID == "bad" {
+				return saveErr
+			}
+			return nil
+		},
+	}
+
+	rec := New(storage, WithMetrics(metrics))
+	if err := rec.RecordRequest(ctx, nil, "req", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if err := rec.RecordRequest(ctx, nil, "bad", []byte("payload"), nil); !errors.Is(err, saveErr) {
+		t.Fatalf("expected save error, got %v", err)
+	}
+	if _, err := rec.GetResponse(ctx, "req"); err != nil {
+		t.Fatalf("GetResponse returned error: %v", err)
+	}
+	if _, err := rec.FindByTag(ctx, "env:dev"); err != nil {
+		t.Fatalf("FindByTag returned error: %v", err)
+	}
+
+	counters := metrics.GetCounters()
+	expected := map[string]int64{
+		"recorder.storage.save.success,type=request":  1,
+		"recorder.storage.save.errors,type=request":   1,
+		"recorder.storage.load.success,type=response": 1,
+		"recorder.storage.find_by_tag.success":        1,
+	}
+	for key, want := range expected {
+		if counters[key] != want {
+			t.Fatalf("expected counter %s=%d, got %v", key, want, counters)
+		}
+	}
+	if len(metrics.GetHistograms()["recorder.storage.save.duration,type=request"]) != 2 {
+		t.Fatalf("expected two save timings, got %v", metrics.GetHistograms())
+	}
+}
+
+func TestInstrumentationDefaultsAndOverrides(t *testing.T) {
+	logger, metrics := Instrumentation()
+	if logger == nil || metrics == nil {
+		t.Fatal("expected default logger and metrics")
+	}
+
+	customLogger := NewLogger(slog.LevelDebug)
+	customMetrics := NewMetrics()
+	logger, metrics = Instrumentation(nil, WithLogger(customLogger), WithMetrics(customMetrics))
+	if logger != customLogger {
+		t.Fatal("expected configured logger to be returned")
+	}
+	if metrics != customMetrics {
+		t.Fatal("expected configured metrics to be returned")
+	}
+}
+
+func TestStorageMiddlewareOrder(t *testing.T) {
+	var calls []string
+	wrap := func(name string) StorageMiddleware {
+		return func(next Storage) Storage {
+			return stubStorage{
+				saveFn: func(ctx context.Context, record Record) error {
+					calls = append(calls, name)
+					return next.Save(ctx, record)
+				},
+			}
+		}
+	}
+	storage := stubStorage{
+		saveFn: func(_ context.Context, _ Record) error {
+			calls = append(calls, "storage")
+			return nil
+		},
+	}
+
+	rec := New(storage, WithStorageMiddleware(wrap("outer"), nil, wrap("inner")))
+	if err := rec.RecordRequest(context.Background(), nil, "req", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if want := []string{"outer", "inner", "storage"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected call order %v, got %v", want, calls)
+	}
+}
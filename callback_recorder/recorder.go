@@ -20,25 +20,42 @@ type Options struct {
 }
 
 func New(opts Options, recorderOpts ...recorder.RecorderOption) recorder.Recorder {
-	return recorder.New(&callbackStorage{opts: opts}, recorderOpts...)
+	logger, _ := recorder.Instrumentation(recorderOpts...)
+	return recorder.New(
+		&callbackStorage{
+			opts:   opts,
+			logger: logger.With("component", "callback_recorder"),
+		},
+		recorderOpts...,
+	)
 }
 
 type callbackStorage struct {
-	opts Options
+	opts   Options
+	logger recorder.Logger
 }
 
 func (s *callbackStorage) Save(ctx context.Context, record recorder.Record) error {
 	if s.opts.Save == nil {
 		return fmt.Errorf("save not supported: no SaveFunc provided")
 	}
-	return s.opts.Save(ctx, record)
+	if err := s.opts.Save(ctx, record); err != nil {
+		s.logger.WithContext(ctx).Error("save callback failed", "error", err)
+		return err
+	}
+	return nil
 }
 
 func (s *callbackStorage) Load(ctx context.Context, recordType recorder.RecordType, requestID string) ([]byte, error) {
 	if s.opts.Load == nil {
 		return nil, fmt.Errorf("load not supported: no LoadFunc provided")
 	}
-	return s.opts.Load(ctx, recordType, requestID)
+	data, err := s.opts.Load(ctx, recordType, requestID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("load callback failed", "error", err)
+		return nil, err
+	}
+	return data, nil
 }
 
 func (s *callbackStorage) FindByTag(ctx context.Context, tag string) ([]string, error) {
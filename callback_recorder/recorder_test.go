@@ -101,3 +101,20 @@ func TestCallbackRecorder_MissingCallbacks(t *testing.T) {
 		t.Fatal("expected error when FindFunc is nil")
 	}
 }
+
+func TestCallbackRecorder_UsesInjectedMetrics(t *testing.T) {
+	metrics := recorder.NewMetrics()
+	rec := New(
+		Options{
+			Save: func(ctx context.Context, r recorder.Record) error { return errors.New("down") },
+		},
+		recorder.WithMetrics(metrics),
+	)
+
+	if err := rec.RecordRequest(context.Background(), nil, "req", []byte("data"), nil); err == nil {
+		t.Fatal("expected save error")
+	}
+	if got := metrics.GetCounters()["recorder.storage.save.errors,type=request"]; got != 1 {
+		t.Fatalf("expected save error counter, got %d", got)
+	}
+}
@@ -0,0 +1,111 @@
+package recorder
+
+import "context"
+
+type contextKey int
+
+const (
+	traceIDKey contextKey = iota
+	requestIDKey
+	primaryIDKey
+	tenantKey
+	recordTypeKey
+)
+
+// Log attribute names attached by the default context extractors.
+const (
+	LogKeyTraceID    = "trace_id"
+	LogKeyRequestID  = "request_id"
+	LogKeyPrimaryID  = "primary_id"
+	LogKeyTenant     = "tenant"
+	LogKeyRecordType = "record_type"
+)
+
+// ContextExtractor returns key/value pairs to attach to a logger built by WithContext.
+type ContextExtractor func(ctx context.Context) []any
+
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func ContextWithPrimaryID(ctx context.Context, primaryID string) context.Context {
+	return context.WithValue(ctx, primaryIDKey, primaryID)
+}
+
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+func ContextWithRecordType(ctx context.Context, recordType RecordType) context.Context {
+	return context.WithValue(ctx, recordTypeKey, recordType)
+}
+
+// TraceIDFromContext also accepts the legacy untyped "trace_id" key.
+func TraceIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(traceIDKey).(string); ok {
+		return id
+	}
+	return getTraceID(ctx)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func PrimaryIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(primaryIDKey).(string)
+	return id
+}
+
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
+
+func RecordTypeFromContext(ctx context.Context) RecordType {
+	recordType, _ := ctx.Value(recordTypeKey).(RecordType)
+	return recordType
+}
+
+var defaultContextExtractors = DefaultContextExtractors()
+
+// DefaultContextExtractors attach trace_id, request_id, primary_id, tenant and
+// record_type when they are present in the context.
+func DefaultContextExtractors() []ContextExtractor {
+	return []ContextExtractor{
+		stringExtractor(LogKeyTraceID, TraceIDFromContext),
+		stringExtractor(LogKeyRequestID, RequestIDFromContext),
+		stringExtractor(LogKeyPrimaryID, PrimaryIDFromContext),
+		stringExtractor(LogKeyTenant, TenantFromContext),
+		stringExtractor(LogKeyRecordType, func(ctx context.Context) string {
+			return string(RecordTypeFromContext(ctx))
+		}),
+	}
+}
+
+func stringExtractor(key string, fn func(context.Context) string) ContextExtractor {
+	return func(ctx context.Context) []any {
+		if value := fn(ctx); value != "" {
+			return []any{key, value}
+		}
+		return nil
+	}
+}
+
+// contextWithRecord carries the fields of record to the storage so that its logs can be
+// correlated with the recorder call that produced them.
+func contextWithRecord(ctx context.Context, recordType RecordType, requestID string, primaryID *string) context.Context {
+	ctx = ContextWithRecordType(ctx, recordType)
+	if requestID != "" {
+		ctx = ContextWithRequestID(ctx, requestID)
+	}
+	if primaryID != nil && *primaryID != "" {
+		ctx = ContextWithPrimaryID(ctx, *primaryID)
+	}
+	return ctx
+}
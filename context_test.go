@@ -0,0 +1,52 @@
+package recorder
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestContextValues(t *testing.T) {
+	ctx := context.Background()
+	ctx = ContextWithTraceID(ctx, "trace-1")
+	ctx = ContextWithRequestID(ctx, "req-1")
+	ctx = ContextWithPrimaryID(ctx, "order-1")
+	ctx = ContextWithTenant(ctx, "acme")
+	ctx = ContextWithRecordType(ctx, RecordTypeResponse)
+
+	if TraceIDFromContext(ctx) != "trace-1" || RequestIDFromContext(ctx) != "req-1" ||
+		PrimaryIDFromContext(ctx) != "order-1" || TenantFromContext(ctx) != "acme" ||
+		RecordTypeFromContext(ctx) != RecordTypeResponse {
+		t.Fatal("expected every context value to round-trip")
+	}
+
+	legacy := context.WithValue(context.Background(), "trace_id", "legacy")
+	if got := TraceIDFromContext(legacy); got != "legacy" {
+		t.Fatalf("expected legacy trace id, got %s", got)
+	}
+}
+
+func TestDefaultContextExtractorsSkipMissingValues(t *testing.T) {
+	ctx := ContextWithTenant(context.Background(), "acme")
+
+	var args []any
+	for _, extract := range DefaultContextExtractors() {
+		args = append(args, extract(ctx)...)
+	}
+	if want := []any{LogKeyTenant, "acme"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("expected %v, got %v", want, args)
+	}
+}
+
+func TestContextWithRecord(t *testing.T) {
+	primary := "order-1"
+	ctx := contextWithRecord(context.Background(), RecordTypeRequest, "req-1", &primary)
+	if RecordTypeFromContext(ctx) != RecordTypeRequest || RequestIDFromContext(ctx) != "req-1" || PrimaryIDFromContext(ctx) != primary {
+		t.Fatal("expected record fields in context")
+	}
+
+	ctx = contextWithRecord(context.Background(), RecordTypeRequest, "req-1", nil)
+	if PrimaryIDFromContext(ctx) != "" {
+		t.Fatal("expected no primary id")
+	}
+}
@@ -0,0 +1,237 @@
+package recorder
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCorrelationWindow     = 5 * time.Minute
+	defaultCorrelationMaxPending = 10000
+)
+
+// Outcomes reported by exchange correlation.
+const (
+	ExchangeOutcomeSuccess = "success"
+	ExchangeOutcomeError   = "error"
+)
+
+type CorrelationOption func(*correlationConfig)
+
+type correlationConfig struct {
+	window        time.Duration
+	maxPending    int
+	tagKeys       []string
+	recordMetrics bool
+}
+
+// WithCorrelation matches every recorded request with the response or error recorded
+// later for the same requestID and reports, through the recorder's Metrics:
+//
+//   - recorder.exchange.duration: timing between request and response/error
+//   - recorder.exchange.success / recorder.exchange.errors: completed exchanges
+//   - recorder.exchange.success_rate: running success ratio
+//   - recorder.exchange.expired: requests that were never answered within the window
+//
+// Metric tags are taken from the request tags listed by CorrelationTagKeys.
+func WithCorrelation(opts ...CorrelationOption) RecorderOption {
+	return func(o *recorderOptions) {
+		cfg := &correlationConfig{
+			window:     defaultCorrelationWindow,
+			maxPending: defaultCorrelationMaxPending,
+		}
+		for _, opt := range opts {
+			if opt != nil {
+				opt(cfg)
+			}
+		}
+		o.correlation = cfg
+	}
+}
+
+// CorrelationWindow sets how long a request waits for its response before it is dropped.
+func CorrelationWindow(window time.Duration) CorrelationOption {
+	return func(cfg *correlationConfig) {
+		if window > 0 {
+			cfg.window = window
+		}
+	}
+}
+
+// CorrelationMaxPending bounds the number of requests waiting for a response; the oldest
+// is dropped when the limit is reached.
+func CorrelationMaxPending(n int) CorrelationOption {
+	return func(cfg *correlationConfig) {
+		if n > 0 {
+			cfg.maxPending = n
+		}
+	}
+}
+
+// CorrelationTagKeys selects the request tags copied onto exchange metrics. Keep the list
+// to low-cardinality tags such as an endpoint or provider name.
+func CorrelationTagKeys(keys ...string) CorrelationOption {
+	return func(cfg *correlationConfig) {
+		cfg.tagKeys = append(cfg.tagKeys, keys...)
+	}
+}
+
+// CorrelationRecordMetrics additionally stores a RecordTypeMetrics record with the
+// computed duration_ms and outcome for every completed exchange.
+func CorrelationRecordMetrics() CorrelationOption {
+	return func(cfg *correlationConfig) {
+		cfg.recordMetrics = true
+	}
+}
+
+// exchange is the outcome of a correlated request.
+type exchange struct {
+	primaryID *string
+	requestID string
+	outcome   string
+	duration  time.Duration
+	tags      map[string]string
+}
+
+func (e exchange) metricsPayload() map[string]string {
+	return map[string]string{
+		"duration_ms": strconv.FormatFloat(float64(e.duration.Nanoseconds())/1e6, 'f', -1, 64),
+		"outcome":     e.outcome,
+	}
+}
+
+type pendingRequest struct {
+	requestID string
+	primaryID *string
+	start     time.Time
+	tags      map[string]string
+}
+
+type exchangeTotals struct {
+	success int64
+	total   int64
+}
+
+// correlator keeps pending requests in insertion order so expiry and the size bound only
+// ever look at the front of the list.
+type correlator struct {
+	cfg     correlationConfig
+	metrics Metrics
+	now     func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	pending map[string]*list.Element
+	totals  map[string]*exchangeTotals
+}
+
+func newCorrelator(cfg correlationConfig, metrics Metrics) *correlator {
+	return &correlator{
+		cfg:     cfg,
+		metrics: metrics,
+		now:     time.Now,
+		order:   list.New(),
+		pending: make(map[string]*list.Element),
+		totals:  make(map[string]*exchangeTotals),
+	}
+}
+
+func (c *correlator) request(record Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expire(c.now())
+	if el, ok := c.pending[record.RequestID]; ok {
+		c.order.Remove(el)
+	}
+	c.pending[record.RequestID] = c.order.PushBack(&pendingRequest{
+		requestID: record.RequestID,
+		primaryID: record.PrimaryID,
+		start:     record.Timestamp,
+		tags:      c.selectTags(record.Tags),
+	})
+	for c.order.Len() > c.cfg.maxPending {
+		c.drop(c.order.Front())
+	}
+}
+
+// complete finishes the exchange started by the matching request. It returns false when
+// no request is pending for record.RequestID.
+func (c *correlator) complete(record Record, outcome string) (exchange, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expire(c.now())
+	el, ok := c.pending[record.RequestID]
+	if !ok {
+		return exchange{}, false
+	}
+	c.order.Remove(el)
+	delete(c.pending, record.RequestID)
+
+	req := el.Value.(*pendingRequest)
+	ex := exchange{
+		primaryID: req.primaryID,
+		requestID: req.requestID,
+		outcome:   outcome,
+		duration:  record.Timestamp.Sub(req.start),
+		tags:      req.tags,
+	}
+	if ex.duration < 0 {
+		ex.duration = 0
+	}
+	c.report(ex)
+	return ex, true
+}
+
+func (c *correlator) report(ex exchange) {
+	c.metrics.RecordTiming("recorder.exchange.duration", ex.duration, ex.tags)
+
+	key := metricKey("", ex.tags)
+	totals, ok := c.totals[key]
+	if !ok {
+		totals = &exchangeTotals{}
+		c.totals[key] = totals
+	}
+	totals.total++
+	if ex.outcome == ExchangeOutcomeSuccess {
+		totals.success++
+		c.metrics.IncrementCounter("recorder.exchange.success", ex.tags)
+	} else {
+		c.metrics.IncrementCounter("recorder.exchange.errors", ex.tags)
+	}
+	c.metrics.SetGauge("recorder.exchange.success_rate", float64(totals.success)/float64(totals.total), ex.tags)
+}
+
+func (c *correlator) expire(now time.Time) {
+	for el := c.order.Front(); el != nil; el = c.order.Front() {
+		if now.Sub(el.Value.(*pendingRequest).start) < c.cfg.window {
+			return
+		}
+		c.drop(el)
+	}
+}
+
+func (c *correlator) drop(el *list.Element) {
+	req := c.order.Remove(el).(*pendingRequest)
+	delete(c.pending, req.requestID)
+	c.metrics.IncrementCounter("recorder.exchange.expired", req.tags)
+}
+
+func (c *correlator) selectTags(tags map[string]string) map[string]string {
+	if len(c.cfg.tagKeys) == 0 || len(tags) == 0 {
+		return nil
+	}
+	selected := make(map[string]string, len(c.cfg.tagKeys))
+	for _, k := range c.cfg.tagKeys {
+		if v, ok := tags[k]; ok {
+			selected[k] = v
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+	return selected
+}
@@ -0,0 +1,119 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCorrelationEmitsExchangeMetrics(t *testing.T) {
+	ctx := context.Background()
+	metrics := NewMetrics()
+	rec := New(stubStorage{}, WithMetrics(metrics), WithCorrelation(CorrelationTagKeys("endpoint")))
+
+	tags := map[string]string{"endpoint": "charge", "user": "u-1"}
+	for _, id := range []string{"ok", "failed"} {
+		if err := rec.RecordRequest(ctx, nil, id, []byte("{}"), tags); err != nil {
+			t.Fatalf("RecordRequest returned error: %v", err)
+		}
+	}
+	if err := rec.RecordResponse(ctx, nil, "ok", []byte("{}"), nil); err != nil {
+		t.Fatalf("RecordResponse returned error: %v", err)
+	}
+	if err := rec.RecordError(ctx, nil, "failed", context.DeadlineExceeded, nil); err != nil {
+		t.Fatalf("RecordError returned error: %v", err)
+	}
+	// A second completion for the same request is not counted twice.
+	if err := rec.RecordResponse(ctx, nil, "ok", []byte("{}"), nil); err != nil {
+		t.Fatalf("RecordResponse returned error: %v", err)
+	}
+
+	counters := metrics.GetCounters()
+	if counters["recorder.exchange.success,endpoint=charge"] != 1 || counters["recorder.exchange.errors,endpoint=charge"] != 1 {
+		t.Fatalf("unexpected exchange counters: %v", counters)
+	}
+	if rate := metrics.GetGauges()["recorder.exchange.success_rate,endpoint=charge"]; rate != 0.5 {
+		t.Fatalf("expected success rate 0.5, got %v", rate)
+	}
+	if h := metrics.GetHistogramSnapshots()["recorder.exchange.duration,endpoint=charge"]; h.Count != 2 {
+		t.Fatalf("expected two exchange timings, got %+v", h)
+	}
+}
+
+func TestCorrelationRecordsMetricsRecord(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var saved []Record
+	storage := stubStorage{saveFn: func(_ context.Context, record Record) error {
+		mu.Lock()
+		saved = append(saved, record)
+		mu.Unlock()
+		return nil
+	}}
+	primary := "order-1"
+	rec := New(storage, WithCorrelation(CorrelationRecordMetrics(), CorrelationTagKeys("endpoint")))
+
+	if err := rec.RecordRequest(ctx, &primary, "req-1", []byte("{}"), map[string]string{"endpoint": "charge"}); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if err := rec.RecordResponse(ctx, nil, "req-1", []byte("{}"), nil); err != nil {
+		t.Fatalf("RecordResponse returned error: %v", err)
+	}
+
+	if len(saved) != 3 || saved[2].Type != RecordTypeMetrics {
+		t.Fatalf("expected a trailing metrics record, got %+v", saved)
+	}
+	record := saved[2]
+	if record.RequestID != "req-1" || record.PrimaryID == nil || *record.PrimaryID != primary || record.Tags["endpoint"] != "charge" {
+		t.Fatalf("unexpected metrics record: %+v", record)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(record.Payload, &payload); err != nil {
+		t.Fatalf("failed to decode metrics payload: %v", err)
+	}
+	if payload["outcome"] != ExchangeOutcomeSuccess || payload["duration_ms"] == "" {
+		t.Fatalf("unexpected metrics payload: %v", payload)
+	}
+}
+
+func TestCorrelatorExpiresAndBoundsPending(t *testing.T) {
+	metrics := NewMetrics()
+	c := newCorrelator(correlationConfig{window: time.Minute, maxPending: 2}, metrics)
+	now := time.Unix(1700000000, 0)
+	c.now = func() time.Time { return now }
+
+	c.request(Record{RequestID: "a", Timestamp: now})
+	c.request(Record{RequestID: "b", Timestamp: now})
+	c.request(Record{RequestID: "c", Timestamp: now})
+	if _, ok := c.complete(Record{RequestID: "a", Timestamp: now}, ExchangeOutcomeSuccess); ok {
+		t.Fatal("expected oldest request to be evicted by the size bound")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.complete(Record{RequestID: "b", Timestamp: now}, ExchangeOutcomeSuccess); ok {
+		t.Fatal("expected request to expire after the window")
+	}
+	if len(c.pending) != 0 || c.order.Len() != 0 {
+		t.Fatalf("expected no pending requests, got %d", len(c.pending))
+	}
+	if expired := metrics.GetCounters()["recorder.exchange.expired"]; expired != 3 {
+		t.Fatalf("expected three expired requests, got %d", expired)
+	}
+}
+
+func TestCorrelatorDuration(t *testing.T) {
+	c := newCorrelator(correlationConfig{window: time.Minute, maxPending: 10}, NewMetrics())
+	start := time.Unix(1700000000, 0)
+	c.now = func() time.Time { return start }
+
+	c.request(Record{RequestID: "a", Timestamp: start})
+	ex, ok := c.complete(Record{RequestID: "a", Timestamp: start.Add(150 * time.Millisecond)}, ExchangeOutcomeError)
+	if !ok || ex.duration != 150*time.Millisecond || ex.outcome != ExchangeOutcomeError {
+		t.Fatalf("unexpected exchange: %+v", ex)
+	}
+	if got := ex.metricsPayload()["duration_ms"]; got != "150" {
+		t.Fatalf("unexpected duration_ms: %s", got)
+	}
+}
@@ -0,0 +1,353 @@
+package recorder
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Detector finds a kind of secret inside free text. Pattern may contain a capture group
+// named "secret"; only that part of a match is then replaced, e.g. the token after
+// "Bearer ".
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Validate, when set, rejects false positives such as numbers failing the Luhn check.
+	// A rejected match is searched again from each space- or dash-separated group, so a
+	// number directly before a card number does not hide it.
+	Validate func(match string) bool
+	// Replacement defaults to "[REDACTED:<Name>]".
+	Replacement string
+}
+
+var (
+	jwtPattern    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]+=*)`)
+	awsKeyPattern = regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA)[0-9A-Z]{16}\b`)
+	cardPattern   = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	ibanPattern   = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	phonePattern  = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?(?:\(\d{1,4}\)[\s.-]?)?|\(\d{1,4}\)[\s.-]?)\d{2,4}(?:[\s.-]?\d{2,4}){1,5}`)
+)
+
+// DetectJWTs finds JSON Web Tokens.
+func DetectJWTs() Detector {
+	return Detector{Name: "jwt", Pattern: jwtPattern}
+}
+
+// DetectBearerTokens finds the credential of "Bearer <token>" strings, keeping the scheme.
+func DetectBearerTokens() Detector {
+	return Detector{Name: "bearer", Pattern: bearerPattern}
+}
+
+// DetectAWSKeys finds AWS access key IDs.
+func DetectAWSKeys() Detector {
+	return Detector{Name: "aws_key", Pattern: awsKeyPattern}
+}
+
+// DetectCardNumbers finds payment card numbers, optionally separated by spaces or dashes,
+// that pass the Luhn check.
+func DetectCardNumbers() Detector {
+	return Detector{Name: "card", Pattern: cardPattern, Validate: func(match string) bool {
+		return luhnValid(match)
+	}}
+}
+
+// DetectIBANs finds IBANs with a valid mod-97 checksum.
+func DetectIBANs() Detector {
+	return Detector{Name: "iban", Pattern: ibanPattern, Validate: func(match string) bool {
+		return ibanValid(stripSeparators(match))
+	}}
+}
+
+// DetectEmails finds email addresses.
+func DetectEmails() Detector {
+	return Detector{Name: "email", Pattern: emailPattern}
+}
+
+// DetectPhoneNumbers finds phone numbers of 9 to 15 digits written in international
+// format (+380 67 123 4567) or with a parenthesized area code ((067) 123-45-67). Bare
+// digit groups are left alone because they are indistinguishable from dates and amounts.
+func DetectPhoneNumbers() Detector {
+	return Detector{Name: "phone", Pattern: phonePattern, Validate: func(match string) bool {
+		digits := len(stripSeparators(strings.NewReplacer("+", "", "(", "", ")", "", ".", "").Replace(match)))
+		return digits >= 9 && digits <= 15
+	}}
+}
+
+// DefaultDetectors returns every built-in detector. Token detectors run before the
+// numeric ones so that digits inside tokens are not reported twice.
+func DefaultDetectors() []Detector {
+	return []Detector{
+		DetectJWTs(),
+		DetectBearerTokens(),
+		DetectAWSKeys(),
+		DetectCardNumbers(),
+		DetectIBANs(),
+		DetectEmails(),
+		DetectPhoneNumbers(),
+	}
+}
+
+// RedactText rewrites every substring of text found by detectors. DefaultDetectors are
+// used when none are given.
+func RedactText(text string, detectors ...Detector) string {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	for _, d := range detectors {
+		text = d.redact(text)
+	}
+	return text
+}
+
+// ContainsSecret reports whether any detector finds a secret in text.
+func ContainsSecret(text string, detectors ...Detector) bool {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	for _, d := range detectors {
+		if d.redact(text) != text {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactSecrets is a ScrubFunc that rewrites secrets found inside string values and
+// leaves other values untouched.
+func RedactSecrets(detectors ...Detector) ScrubFunc {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	return func(ctx FieldContext) any {
+		if text, ok := ctx.Value.(string); ok {
+			return RedactText(text, detectors...)
+		}
+		return ctx.Value
+	}
+}
+
+// MatchSecrets matches string values in which a detector finds a secret.
+func MatchSecrets(detectors ...Detector) FieldMatcher {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	return func(ctx FieldContext) bool {
+		text, ok := ctx.Value.(string)
+		return ok && ContainsSecret(text, detectors...)
+	}
+}
+
+// SecretsRule rewrites secrets inside any string value. It continues to the next rule so
+// key-based rules still apply to the same field.
+func SecretsRule(detectors ...Detector) Rule {
+	return Rule{
+		Name:     "free-text-secrets",
+		Match:    MatchSecrets(detectors...),
+		Apply:    RedactSecrets(detectors...),
+		Continue: true,
+	}
+}
+
+// WithDetectors adds SecretsRule to the scrubber and lets ScrubContent redact plain-text
+// payloads, such as error messages, that are neither JSON, XML nor form-encoded.
+// DefaultDetectors are used when none are given.
+func WithDetectors(detectors ...Detector) ScrubberOption {
+	return func(s *Scrubber) {
+		if len(detectors) == 0 {
+			detectors = DefaultDetectors()
+		}
+		s.detectors = append(s.detectors, detectors...)
+		s.rules = append(s.rules, SecretsRule(detectors...))
+	}
+}
+
+// ScrubText redacts secrets in a plain-text payload with the scrubber's detectors, or
+// DefaultDetectors when none were configured.
+func (s *Scrubber) ScrubText(data []byte) []byte {
+	if s == nil {
+		return []byte(RedactText(string(data)))
+	}
+	return []byte(s.redactText(string(data), nil))
+}
+
+// redactText redacts text with the scrubber's detectors, reporting matches under path.
+func (s *Scrubber) redactText(text string, path []string) string {
+	detectors := s.currentDetectors()
+	if s.report == nil {
+		return RedactText(text, detectors...)
+	}
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	for _, d := range detectors {
+		if redacted := d.redact(text); redacted != text {
+			s.report.add(path, d.Name)
+			text = redacted
+		}
+	}
+	return text
+}
+
+// TextPayloadScrubber redacts secrets in payloads treated as plain text.
+func TextPayloadScrubber(detectors ...Detector) PayloadScrubFunc {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	return func(_ RecordType, payload []byte) ([]byte, error) {
+		return []byte(RedactText(string(payload), detectors...)), nil
+	}
+}
+
+func (s *Scrubber) currentDetectors() []Detector {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.detectors
+}
+
+func (d Detector) redact(text string) string {
+	if d.Pattern == nil {
+		return text
+	}
+	replacement := d.Replacement
+	if replacement == "" {
+		replacement = "[REDACTED:" + d.Name + "]"
+	}
+	secret := d.Pattern.SubexpIndex("secret")
+
+	matches := d.Pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last, replaced := 0, false
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if end <= last {
+			continue
+		}
+		if secret > 0 && m[2*secret] >= 0 {
+			start, end = m[2*secret], m[2*secret+1]
+		}
+		if d.Validate != nil && (start < last || !d.Validate(text[start:end])) {
+			var ok bool
+			if start, end, ok = d.retry(text, max(start, last), end); !ok {
+				continue
+			}
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(replacement)
+		last, replaced = end, true
+	}
+	if !replaced {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// retry looks for a valid match starting at a group boundary in [from, to). A greedy
+// match that joins a neighbouring number, such as a quantity before a card number, fails
+// validation as a whole and would otherwise hide the secret. The match found may end
+// after to.
+func (d Detector) retry(text string, from, to int) (int, int, bool) {
+	pattern := anchoredPattern(d.Pattern)
+	for start := from; start < to; start++ {
+		if isSeparator(text[start]) || (start > 0 && !isSeparator(text[start-1])) {
+			continue
+		}
+		loc := pattern.FindStringIndex(text[start:])
+		if loc == nil {
+			continue
+		}
+		for end := start + loc[1]; end > start; end-- {
+			if end < len(text) && (!isSeparator(text[end]) || isSeparator(text[end-1])) {
+				continue
+			}
+			// Validate is usually far cheaper than matching the candidate again.
+			candidate := text[start:end]
+			if !d.Validate(candidate) {
+				continue
+			}
+			if l := pattern.FindStringIndex(candidate); l != nil && l[1] == len(candidate) {
+				return start, end, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// anchoredPatterns caches the anchored copies of detector patterns used by retry.
+var anchoredPatterns sync.Map
+
+// anchoredPattern returns pattern anchored at the start of the text, so that retry stops
+// at the first position instead of scanning the rest of the text for a later match.
+func anchoredPattern(pattern *regexp.Regexp) *regexp.Regexp {
+	if cached, ok := anchoredPatterns.Load(pattern); ok {
+		return cached.(*regexp.Regexp)
+	}
+	anchored := regexp.MustCompile(`^(?:` + pattern.String() + `)`)
+	cached, _ := anchoredPatterns.LoadOrStore(pattern, anchored)
+	return cached.(*regexp.Regexp)
+}
+
+func isSeparator(c byte) bool {
+	return c == ' ' || c == '-'
+}
+
+func stripSeparators(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// luhnValid checks a card number of 13 to 19 digits. Separators are skipped rather than
+// stripped, since retry calls it for many candidates.
+func luhnValid(number string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if isSeparator(c) {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
+
+func ibanValid(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for i := 0; i < len(rearranged); i++ {
+		c := rearranged[i]
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
@@ -0,0 +1,110 @@
+package recorder
+
+import (
+	"context"
+	"os"
+)
+
+// TagExtractor derives tags from the context of a Record* call.
+type TagExtractor func(ctx context.Context) map[string]string
+
+// TagConflictPolicy decides which value is kept when an enriched tag is also passed by
+// the caller.
+type TagConflictPolicy int
+
+const (
+	// TagConflictCallerWins keeps the value passed to the Record* call.
+	TagConflictCallerWins TagConflictPolicy = iota
+	// TagConflictEnricherWins keeps the static or context-derived value.
+	TagConflictEnricherWins
+)
+
+type tagEnrichment struct {
+	static     map[string]string
+	extractors []TagExtractor
+	policy     TagConflictPolicy
+}
+
+// WithStaticTags adds tags to every record. Repeated calls merge, later values winning.
+func WithStaticTags(tags map[string]string) RecorderOption {
+	return func(o *recorderOptions) {
+		if len(tags) == 0 {
+			return
+		}
+		if o.enrichment.static == nil {
+			o.enrichment.static = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			o.enrichment.static[k] = v
+		}
+	}
+}
+
+// WithContextTags adds the tags returned by extractors to every record. Extractors run in
+// order after the static tags, so later extractors override earlier ones.
+func WithContextTags(extractors ...TagExtractor) RecorderOption {
+	return func(o *recorderOptions) {
+		for _, extract := range extractors {
+			if extract != nil {
+				o.enrichment.extractors = append(o.enrichment.extractors, extract)
+			}
+		}
+	}
+}
+
+// WithTagConflictPolicy sets how enriched tags merge with caller tags. The default is
+// TagConflictCallerWins.
+func WithTagConflictPolicy(policy TagConflictPolicy) RecorderOption {
+	return func(o *recorderOptions) {
+		o.enrichment.policy = policy
+	}
+}
+
+// EnvTags maps tag names to environment variables, e.g. {"version": "APP_VERSION"}.
+// Variables that are unset or empty are skipped.
+func EnvTags(mapping map[string]string) map[string]string {
+	tags := make(map[string]string, len(mapping))
+	for tag, env := range mapping {
+		if value := os.Getenv(env); value != "" {
+			tags[tag] = value
+		}
+	}
+	return tags
+}
+
+// HostTags returns {"host": os.Hostname()}, or nil when the hostname is unknown.
+func HostTags() map[string]string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return nil
+	}
+	return map[string]string{"host": host}
+}
+
+// ContextValueTag returns an extractor that stores the string produced by fn under key,
+// e.g. ContextValueTag("tenant", TenantFromContext).
+func ContextValueTag(key string, fn func(context.Context) string) TagExtractor {
+	return func(ctx context.Context) map[string]string {
+		if value := fn(ctx); value != "" {
+			return map[string]string{key: value}
+		}
+		return nil
+	}
+}
+
+func (e tagEnrichment) enabled() bool {
+	return len(e.static) > 0 || len(e.extractors) > 0
+}
+
+func (e tagEnrichment) tags(ctx context.Context) map[string]string {
+	enriched := make(map[string]string, len(e.static))
+	for k, v := range e.static {
+		enriched[k] = v
+	}
+	for _, extract := range e.extractors {
+		for k, v := range extract(ctx) {
+			enriched[k] = v
+		}
+	}
+	return enriched
+}
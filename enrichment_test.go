@@ -0,0 +1,86 @@
+package recorder
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTagEnrichmentMergesStaticAndContextTags(t *testing.T) {
+	var stored Record
+	storage := stubStorage{saveFn: func(_ context.Context, record Record) error {
+		stored = record
+		return nil
+	}}
+
+	rec := New(storage,
+		WithStaticTags(map[string]string{"service": "payments", "env": "prod"}),
+		WithStaticTags(map[string]string{"env": "staging"}),
+		WithContextTags(
+			ContextValueTag("tenant", TenantFromContext),
+			func(context.Context) map[string]string { return map[string]string{"tenant": "override"} },
+		),
+	)
+
+	ctx := ContextWithTenant(context.Background(), "acme")
+	callerTags := map[string]string{"env": "caller", "endpoint": "charge"}
+	if err := rec.RecordRequest(ctx, nil, "req", []byte("{}"), callerTags); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	want := map[string]string{"service": "payments", "env": "caller", "tenant": "override", "endpoint": "charge"}
+	if !reflect.DeepEqual(stored.Tags, want) {
+		t.Fatalf("expected tags %v, got %v", want, stored.Tags)
+	}
+	if len(callerTags) != 2 {
+		t.Fatalf("expected caller tags to be left untouched, got %v", callerTags)
+	}
+}
+
+func TestTagEnrichmentEnricherWins(t *testing.T) {
+	var stored Record
+	storage := stubStorage{saveFn: func(_ context.Context, record Record) error {
+		stored = record
+		return nil
+	}}
+
+	rec := New(storage,
+		WithStaticTags(map[string]string{"env": "prod"}),
+		WithTagConflictPolicy(TagConflictEnricherWins),
+	)
+	if err := rec.RecordError(context.Background(), nil, "req", context.Canceled, map[string]string{"env": "dev", "a": "b"}); err != nil {
+		t.Fatalf("RecordError returned error: %v", err)
+	}
+	if !reflect.DeepEqual(stored.Tags, map[string]string{"env": "prod", "a": "b", ErrorClassTag: ErrorClassCanceled}) {
+		t.Fatalf("unexpected tags: %v", stored.Tags)
+	}
+}
+
+func TestEnrichedTagsAreScrubbed(t *testing.T) {
+	var stored Record
+	storage := stubStorage{saveFn: func(_ context.Context, record Record) error {
+		stored = record
+		return nil
+	}}
+
+	rec := New(storage, WithScrubber(NewScrubber()), WithContextTags(func(context.Context) map[string]string {
+		return map[string]string{"token": "abc"}
+	}))
+	if err := rec.RecordRequest(context.Background(), nil, "req", []byte("{}"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if stored.Tags["token"] != "[REDACTED]" {
+		t.Fatalf("expected enriched tag to be scrubbed, got %v", stored.Tags)
+	}
+}
+
+func TestEnvTags(t *testing.T) {
+	t.Setenv("RECORDER_TEST_VERSION", "1.2.3")
+	tags := EnvTags(map[string]string{"version": "RECORDER_TEST_VERSION", "missing": "RECORDER_TEST_UNSET"})
+	if !reflect.DeepEqual(tags, map[string]string{"version": "1.2.3"}) {
+		t.Fatalf("unexpected env tags: %v", tags)
+	}
+	if host := HostTags(); host != nil && host["host"] == "" {
+		t.Fatalf("unexpected host tags: %v", host)
+	}
+}
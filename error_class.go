@@ -0,0 +1,154 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// ErrorClassTag is the tag under which RecordError stores the class of an error, so that
+// FindByTag("error_class:timeout") finds every timeout.
+const ErrorClassTag = "error_class"
+
+// Error classes assigned by DefaultErrorClassifier.
+const (
+	ErrorClassTimeout     = "timeout"
+	ErrorClassCanceled    = "canceled"
+	ErrorClassNetwork     = "network"
+	ErrorClassClientError = "client_error"
+	ErrorClassServerError = "server_error"
+	ErrorClassValidation  = "validation"
+	ErrorClassUnknown     = "unknown"
+)
+
+// ErrValidation marks invalid input. Wrap it, e.g. fmt.Errorf("%w: amount", ErrValidation),
+// to have the error classified as ErrorClassValidation.
+var ErrValidation = errors.New("validation failed")
+
+// ErrorClassification describes an error recorded by RecordError. It is stored in the
+// top level of the ErrorRecord.
+type ErrorClassification struct {
+	Class      string `json:"class,omitempty"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	Retryable  bool   `json:"retryable,omitempty"`
+}
+
+// ErrorClassifier classifies err, reporting false when it does not recognize it.
+type ErrorClassifier func(err error) (ErrorClassification, bool)
+
+// WithErrorClassifier adds classifiers consulted by RecordError before
+// DefaultErrorClassifier. The first classifier that recognizes an error wins.
+func WithErrorClassifier(classifiers ...ErrorClassifier) RecorderOption {
+	return func(o *recorderOptions) {
+		for _, c := range classifiers {
+			if c != nil {
+				o.errorClassifiers = append(o.errorClassifiers, c)
+			}
+		}
+	}
+}
+
+// HTTPStatusError attaches the HTTP status of a failed provider call to an error.
+// Errors anywhere in the chain that implement HTTPStatus() int or StatusCode() int are
+// recognized too.
+type HTTPStatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *HTTPStatusError) Error() string {
+	text := "HTTP " + strconv.Itoa(e.StatusCode)
+	if status := http.StatusText(e.StatusCode); status != "" {
+		text += " " + status
+	}
+	if e.Err == nil {
+		return text
+	}
+	return text + ": " + e.Err.Error()
+}
+
+func (e *HTTPStatusError) Unwrap() error { return e.Err }
+
+// HTTPStatus returns the status code.
+func (e *HTTPStatusError) HTTPStatus() int { return e.StatusCode }
+
+// ClassifyError runs the classifiers in order and falls back to DefaultErrorClassifier.
+func ClassifyError(err error, classifiers ...ErrorClassifier) ErrorClassification {
+	for _, c := range classifiers {
+		if class, ok := c(err); ok {
+			return class
+		}
+	}
+	return DefaultErrorClassifier(err)
+}
+
+// DefaultErrorClassifier recognizes context cancellation and deadlines, HTTP statuses,
+// ErrValidation, and network errors from the net package and syscalls; anything else is
+// ErrorClassUnknown. Timeouts, network errors, 5xx (except 501) and 429 statuses are
+// retryable. An error in the chain implementing Retryable() bool overrides that.
+func DefaultErrorClassifier(err error) ErrorClassification {
+	class := ErrorClassification{Class: ErrorClassUnknown}
+	var netErr net.Error
+	switch status := httpStatus(err); {
+	case errors.Is(err, context.Canceled):
+		class.Class = ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		class.Class, class.Retryable = ErrorClassTimeout, true
+	case status != 0:
+		class = classifyHTTPStatus(status)
+	case errors.Is(err, ErrValidation):
+		class.Class = ErrorClassValidation
+	case errors.As(err, &netErr) && netErr.Timeout():
+		class.Class, class.Retryable = ErrorClassTimeout, true
+	case netErr != nil, isNetworkError(err):
+		class.Class, class.Retryable = ErrorClassNetwork, true
+	}
+
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) {
+		class.Retryable = retryable.Retryable()
+	}
+	return class
+}
+
+func classifyHTTPStatus(status int) ErrorClassification {
+	class := ErrorClassification{Class: ErrorClassUnknown, HTTPStatus: status}
+	switch {
+	case status == http.StatusRequestTimeout, status == http.StatusGatewayTimeout:
+		class.Class, class.Retryable = ErrorClassTimeout, true
+	case status == http.StatusUnprocessableEntity:
+		class.Class = ErrorClassValidation
+	case status >= 400 && status < 500:
+		class.Class, class.Retryable = ErrorClassClientError, status == http.StatusTooManyRequests
+	case status >= 500 && status < 600:
+		class.Class, class.Retryable = ErrorClassServerError, status != http.StatusNotImplemented
+	}
+	return class
+}
+
+// httpStatus returns the first HTTP status found in the chain of err, or 0.
+func httpStatus(err error) int {
+	var withStatus interface{ HTTPStatus() int }
+	if errors.As(err, &withStatus) {
+		return withStatus.HTTPStatus()
+	}
+	var withCode interface{ StatusCode() int }
+	if errors.As(err, &withCode) {
+		return withCode.StatusCode()
+	}
+	return 0
+}
+
+func isNetworkError(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,93 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+)
+
+type retryableError struct{ retry bool }
+
+func (e retryableError) Error() string   { return "custom" }
+func (e retryableError) Retryable() bool { return e.retry }
+
+type statusCoder int
+
+func (s statusCoder) Error() string   { return "status" }
+func (s statusCoder) StatusCode() int { return int(s) }
+
+func TestDefaultErrorClassifier(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want ErrorClassification
+	}{
+		{"canceled", fmt.Errorf("call: %w", context.Canceled), ErrorClassification{Class: ErrorClassCanceled}},
+		{"deadline", context.DeadlineExceeded, ErrorClassification{Class: ErrorClassTimeout, Retryable: true}},
+		{"net timeout", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, ErrorClassification{Class: ErrorClassTimeout, Retryable: true}},
+		{"refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, ErrorClassification{Class: ErrorClassNetwork, Retryable: true}},
+		{"reset", fmt.Errorf("read: %w", syscall.ECONNRESET), ErrorClassification{Class: ErrorClassNetwork, Retryable: true}},
+		{"404", &HTTPStatusError{StatusCode: http.StatusNotFound}, ErrorClassification{Class: ErrorClassClientError, HTTPStatus: 404}},
+		{"429", &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, ErrorClassification{Class: ErrorClassClientError, HTTPStatus: 429, Retryable: true}},
+		{"422", &HTTPStatusError{StatusCode: http.StatusUnprocessableEntity}, ErrorClassification{Class: ErrorClassValidation, HTTPStatus: 422}},
+		{"503", fmt.Errorf("charge: %w", statusCoder(503)), ErrorClassification{Class: ErrorClassServerError, HTTPStatus: 503, Retryable: true}},
+		{"504", &HTTPStatusError{StatusCode: http.StatusGatewayTimeout}, ErrorClassification{Class: ErrorClassTimeout, HTTPStatus: 504, Retryable: true}},
+		{"validation", fmt.Errorf("%w: amount must be positive", ErrValidation), ErrorClassification{Class: ErrorClassValidation}},
+		{"override", retryableError{retry: true}, ErrorClassification{Class: ErrorClassUnknown, Retryable: true}},
+		{"unknown", errors.New("boom"), ErrorClassification{Class: ErrorClassUnknown}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DefaultErrorClassifier(tc.err); got != tc.want {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecordErrorClassifiesAndTags(t *testing.T) {
+	errDeclined := errors.New("card declined")
+	storage := newMemoryStorage()
+	rec := New(storage, WithErrorClassifier(func(err error) (ErrorClassification, bool) {
+		if errors.Is(err, errDeclined) {
+			return ErrorClassification{Class: "declined"}, true
+		}
+		return ErrorClassification{}, false
+	}))
+	ctx := context.Background()
+
+	if err := rec.RecordError(ctx, nil, "req-1", fmt.Errorf("charge: %w", errDeclined), nil); err != nil {
+		t.Fatalf("RecordError returned error: %v", err)
+	}
+	if err := rec.RecordError(ctx, nil, "req-2", &HTTPStatusError{StatusCode: 502, Err: errors.New("bad gateway")}, nil); err != nil {
+		t.Fatalf("RecordError returned error: %v", err)
+	}
+	if err := rec.RecordError(ctx, nil, "req-3", context.Canceled, map[string]string{ErrorClassTag: "shutdown"}); err != nil {
+		t.Fatalf("RecordError returned error: %v", err)
+	}
+
+	errs := storage.records[RecordTypeError]
+	if got := errs["req-1"].Tags[ErrorClassTag]; got != "declined" {
+		t.Fatalf("expected the custom class, got %q", got)
+	}
+	if got := errs["req-3"].Tags[ErrorClassTag]; got != "shutdown" {
+		t.Fatalf("expected the caller's tag to win, got %q", got)
+	}
+
+	var record ErrorRecord
+	if err := json.Unmarshal(errs["req-2"].Payload, &record); err != nil {
+		t.Fatalf("invalid error payload: %v", err)
+	}
+	want := ErrorClassification{Class: ErrorClassServerError, HTTPStatus: 502, Retryable: true}
+	if record.ErrorClassification != want || record.Message != "HTTP 502 Bad Gateway: bad gateway" {
+		t.Fatalf("unexpected error record %+v", record)
+	}
+	if errs["req-2"].Tags[ErrorClassTag] != ErrorClassServerError {
+		t.Fatalf("unexpected tags %v", errs["req-2"].Tags)
+	}
+}
@@ -0,0 +1,106 @@
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strconv"
+)
+
+// ContentTypeErrorRecord is the content type of the ErrorRecord payloads stored by
+// RecordError.
+const ContentTypeErrorRecord = "application/vnd.recorder.error+json"
+
+const (
+	// maxErrorDepth bounds how deep an error chain is followed.
+	maxErrorDepth = 32
+	// maxErrorStack bounds the frames captured by WithErrorStacks.
+	maxErrorStack = 32
+)
+
+// ErrorRecord is the JSON document RecordError stores for an error. Causes hold the
+// errors returned by Unwrap, one for a wrapped error and several for errors.Join. The
+// classification is only set on the top-level record.
+type ErrorRecord struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	ErrorClassification
+	Causes []ErrorRecord `json:"causes,omitempty"`
+	Stack  []string      `json:"stack,omitempty"`
+}
+
+// NewErrorRecord captures the message and type of err and of every error in its chain.
+func NewErrorRecord(err error) ErrorRecord {
+	return newErrorRecord(err, 0)
+}
+
+func newErrorRecord(err error, depth int) ErrorRecord {
+	record := ErrorRecord{Message: err.Error(), Type: fmt.Sprintf("%T", err)}
+	if depth >= maxErrorDepth {
+		return record
+	}
+	var causes []error
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		causes = e.Unwrap()
+	case interface{ Unwrap() error }:
+		causes = []error{e.Unwrap()}
+	}
+	for _, cause := range causes {
+		if cause != nil {
+			record.Causes = append(record.Causes, newErrorRecord(cause, depth+1))
+		}
+	}
+	return record
+}
+
+// WithErrorStacks makes RecordError store the stack of its caller in ErrorRecord.Stack.
+// Errors recorded through Async() capture the stack of the recording goroutine.
+func WithErrorStacks() RecorderOption {
+	return func(o *recorderOptions) {
+		o.errorStacks = true
+	}
+}
+
+// callerStack formats the stack above the caller of callerStack, skipping skip frames.
+func callerStack(skip int) []string {
+	pcs := make([]uintptr, maxErrorStack)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]string, 0, n)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, frame.Function+" ("+frame.File+":"+strconv.Itoa(frame.Line)+")")
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// ScrubErrorRecord scrubs an ErrorRecord payload. The scrubber's detectors, or
+// DefaultDetectors when none are configured, redact secrets inside every message; the
+// rules are then applied to the document as to any JSON payload. A payload that is not
+// an ErrorRecord, such as a message stored by older versions, is scrubbed as plain text.
+func (s *Scrubber) ScrubErrorRecord(data []byte) ([]byte, error) {
+	if s == nil || len(data) == 0 {
+		return append([]byte(nil), data...), nil
+	}
+	var record ErrorRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.Message == "" {
+		return s.ScrubText(data), nil
+	}
+	s.redactMessages(&record, nil)
+	redacted, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("scrubber: encode error record: %w", err)
+	}
+	return s.ScrubJSONOrdered(redacted)
+}
+
+func (s *Scrubber) redactMessages(record *ErrorRecord, path []string) {
+	record.Message = s.redactText(record.Message, appendPath(path, "message"))
+	for i := range record.Causes {
+		s.redactMessages(&record.Causes[i], appendPath(appendPath(path, "causes"), "["+strconv.Itoa(i)+"]"))
+	}
+}
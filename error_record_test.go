@@ -0,0 +1,99 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewErrorRecordCapturesChain(t *testing.T) {
+	base := &fs.PathError{Op: "open", Path: "/tmp/x", Err: fs.ErrNotExist}
+	err := fmt.Errorf("load config: %w", errors.Join(base, context.Canceled))
+
+	got := NewErrorRecord(err)
+	want := ErrorRecord{
+		Message: err.Error(),
+		Type:    "*fmt.wrapError",
+		Causes: []ErrorRecord{{
+			Message: "open /tmp/x: file does not exist\ncontext canceled",
+			Type:    "*errors.joinError",
+			Causes: []ErrorRecord{
+				{
+					Message: "open /tmp/x: file does not exist",
+					Type:    "*fs.PathError",
+					Causes:  []ErrorRecord{{Message: "file does not exist", Type: "*errors.errorString"}},
+				},
+				{Message: "context canceled", Type: "*errors.errorString"},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected record:\n%+v", got)
+	}
+}
+
+func TestRecordErrorStoresScrubbedErrorRecord(t *testing.T) {
+	storage := newMemoryStorage()
+	rec := New(storage, WithScrubber(NewScrubber()), WithErrorStacks())
+
+	cause := errors.New("provider rejected Bearer abcdef123456 for jane@example.com")
+	if err := rec.RecordError(context.Background(), nil, "req-1", fmt.Errorf("charge: %w", cause), nil); err != nil {
+		t.Fatalf("RecordError returned error: %v", err)
+	}
+
+	stored := storage.records[RecordTypeError]["req-1"]
+	if stored.ContentType != ContentTypeErrorRecord {
+		t.Fatalf("unexpected content type %q", stored.ContentType)
+	}
+	if strings.Contains(string(stored.Payload), "jane@example.com") || strings.Contains(string(stored.Payload), "abcdef123456") {
+		t.Fatalf("expected messages to be scrubbed, got %s", stored.Payload)
+	}
+	var record ErrorRecord
+	if err := json.Unmarshal(stored.Payload, &record); err != nil {
+		t.Fatalf("invalid error payload: %v", err)
+	}
+	if len(record.Causes) != 1 || record.Causes[0].Type != "*errors.errorString" {
+		t.Fatalf("expected the wrapped cause, got %+v", record)
+	}
+	if len(record.Stack) == 0 || !strings.Contains(record.Stack[0], "TestRecordErrorStoresScrubbedErrorRecord") {
+		t.Fatalf("expected the stack to start at the caller, got %v", record.Stack)
+	}
+}
+
+func TestScrubErrorRecordReportsMessagePaths(t *testing.T) {
+	audited, report := NewScrubber(WithDetectors(DetectEmails())).Audited()
+	payload, _ := json.Marshal(NewErrorRecord(fmt.Errorf("notify: %w", errors.New("bounced: a@b.io"))))
+
+	out, err := audited.ScrubContent(ContentTypeErrorRecord, payload)
+	if err != nil {
+		t.Fatalf("ScrubContent returned error: %v", err)
+	}
+	if strings.Contains(string(out), "a@b.io") {
+		t.Fatalf("expected emails to be redacted, got %s", out)
+	}
+	if paths := report.Paths(); !reflect.DeepEqual(paths, []string{"causes.[0].message", "message"}) {
+		t.Fatalf("unexpected report paths %v", paths)
+	}
+
+	legacy, err := NewScrubber().ScrubErrorRecord([]byte("failed for a@b.io"))
+	if err != nil || string(legacy) != "failed for [REDACTED:email]" {
+		t.Fatalf("expected plain-text fallback, got %q (%v)", legacy, err)
+	}
+}
+
+func TestRecordMetricsScrubbedAsJSON(t *testing.T) {
+	storage := newMemoryStorage()
+	rec := New(storage, WithScrubber(NewScrubber(), ScrubberContentType(ContentTypeXML), ScrubberFailOnError()))
+
+	if err := rec.RecordMetrics(context.Background(), nil, "req-1", map[string]string{"api_key": "k", "duration": "10"}, nil); err != nil {
+		t.Fatalf("RecordMetrics returned error: %v", err)
+	}
+	if got := string(storage.records[RecordTypeMetrics]["req-1"].Payload); got != `{"api_key":"[REDACTED]","duration":"10"}` {
+		t.Fatalf("unexpected metrics payload %s", got)
+	}
+}
@@ -13,20 +13,22 @@ import (
 type fileStorage struct {
 	basePath string
 	mu       sync.Mutex
+	logger   recorder.Logger
 }
 
 // NewFileRecorder creates a Recorder backed by local file storage.
 func NewFileRecorder(basePath string, recorderOpts ...recorder.RecorderOption) recorder.Recorder {
+	logger, _ := recorder.Instrumentation(recorderOpts...)
 	return recorder.New(
 		&fileStorage{
 			basePath: basePath,
+			logger:   logger.With("component", "file_recorder"),
 		},
 		recorderOpts...,
 	)
 }
 
 func (s *fileStorage) Save(ctx context.Context, record recorder.Record) error {
-	_ = ctx
 	if len(record.Payload) == 0 {
 		return fmt.Errorf("data cannot be nil or empty")
 	}
@@ -41,17 +43,25 @@ func (s *fileStorage) Save(ctx context.Context, record recorder.Record) error {
 		id = fmt.Sprintf("%s_%s", *record.PrimaryID, id)
 	}
 
-	return s.saveToFile(prefix, id, record.Payload)
+	if err := s.saveToFile(prefix, id, record.Payload); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save record", "prefix", prefix, "id", id, "error", err)
+		return err
+	}
+	return nil
 }
 
 func (s *fileStorage) Load(ctx context.Context, recordType recorder.RecordType, requestID string) ([]byte, error) {
-	_ = ctx
 	prefix, err := s.prefixFor(recordType)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.loadFromFile(prefix, requestID)
+	data, err := s.loadFromFile(prefix, requestID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to load record", "prefix", prefix, "id", requestID, "error", err)
+		return nil, err
+	}
+	return data, nil
 }
 
 func (s *fileStorage) FindByTag(ctx context.Context, tag string) ([]string, error) {
@@ -69,6 +79,8 @@ func (s *fileStorage) prefixFor(recordType recorder.RecordType) (string, error)
 		return "errors", nil
 	case recorder.RecordTypeMetrics:
 		return "metrics", nil
+	case recorder.RecordTypeScrubReport:
+		return "scrub_reports", nil
 	default:
 		return "", fmt.Errorf("unsupported record type: %s", recordType)
 	}
@@ -57,7 +57,7 @@ func TestFileStorageFindByTagUnsupported(t *testing.T) {
 
 func TestFileStorageErrorPaths(t *testing.T) {
 	dir := t.TempDir()
-	storage := &fileStorage{basePath: dir}
+	storage := &fileStorage{basePath: dir, logger: recorder.NewDefaultLogger()}
 
 	if err := storage.Save(context.Background(), recorder.Record{Type: recorder.RecordTypeRequest, RequestID: "req", Payload: nil}); err == nil {
 		t.Fatal("expected error for empty payload")
@@ -71,3 +71,37 @@ func TestFileStorageErrorPaths(t *testing.T) {
 		t.Fatal("expected error when loading missing file")
 	}
 }
+
+func TestFileRecorderUsesInjectedMetrics(t *testing.T) {
+	metrics := recorder.NewMetrics()
+	rec := NewFileRecorder(t.TempDir(), recorder.WithMetrics(metrics))
+
+	if err := rec.RecordRequest(context.Background(), nil, "req", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if _, err := rec.GetResponse(context.Background(), "missing"); err == nil {
+		t.Fatal("expected error when loading missing response")
+	}
+
+	counters := metrics.GetCounters()
+	if counters["recorder.storage.save.success,type=request"] != 1 {
+		t.Fatalf("expected save counter, got %v", counters)
+	}
+	if counters["recorder.storage.load.errors,type=response"] != 1 {
+		t.Fatalf("expected load error counter, got %v", counters)
+	}
+}
+
+func TestFileRecorderStoresScrubReports(t *testing.T) {
+	dir := t.TempDir()
+	rec := NewFileRecorder(dir, recorder.WithScrubber(recorder.NewScrubber(), recorder.ScrubberReportRecord()))
+
+	if err := rec.RecordRequest(context.Background(), nil, "req3", []byte(`{"password":"p"}`), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	path := filepath.Join(dir, "scrub_reports", recorder.ScrubReportID(recorder.RecordTypeRequest, "req3")+".json")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected scrub report at %s: %v", path, err)
+	}
+}
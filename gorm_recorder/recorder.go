@@ -26,8 +26,10 @@ var (
 )
 
 type gormStorage[R RecordModel, T TagModel] struct {
-	db   *gorm.DB
-	opts modelOptions[R, T]
+	db      *gorm.DB
+	opts    modelOptions[R, T]
+	logger  recorder.Logger
+	metrics recorder.Metrics
 }
 
 // NewRecorder constructs a recorder backed by GORM using the default models provided by the package.
@@ -58,9 +60,12 @@ func newRecorderWithPreparedOptions[R RecordModel, T TagModel](db *gorm.DB, opts
 		return nil, fmt.Errorf("gorm recorder: auto migrate failed: %w", err)
 	}
 
+	logger, metrics := recorder.Instrumentation(recorderOpts...)
 	storage := &gormStorage[R, T]{
-		db:   db,
-		opts: opts,
+		db:      db,
+		opts:    opts,
+		logger:  logger.With("component", "gorm_recorder"),
+		metrics: metrics,
 	}
 	return recorder.New(storage, recorderOpts...), nil
 }
@@ -95,14 +100,18 @@ func (s *gormStorage[R, T]) Save(ctx context.Context, record recorder.Record) er
 		// Check if it's a deadlock error
 		if isDeadlockError(err) {
 			atomic.AddInt64(&deadlockCounter, 1)
+			s.metrics.IncrementCounter("gorm.save.deadlocks", map[string]string{"type": string(record.Type)})
+			s.logger.WithContext(ctx).Warn("deadlock while saving record, retrying", "attempt", attempt, "error", err)
 			lastErr = err
 			continue
 		}
 
 		// If it's not a deadlock, return immediately
+		s.logger.WithContext(ctx).Error("failed to save record", "error", err)
 		return err
 	}
 
+	s.logger.WithContext(ctx).Error("giving up on record after deadlocks", "retries", maxRetries, "error", lastErr)
 	return fmt.Errorf("gorm recorder: failed after %d retries due to deadlocks: %w", maxRetries, lastErr)
 }
 
@@ -204,3 +204,23 @@ func (t *customTagModel) SetKey(key string) {
 func (t *customTagModel) SetValue(value string) {
 	t.Value = value
 }
+
+func TestGORMRecorderUsesInjectedMetrics(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite DB: %v", err)
+	}
+
+	metrics := recorder.NewMetrics()
+	rec, err := NewRecorder(db, recorder.WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("failed to create gorm recorder: %v", err)
+	}
+
+	if err := rec.RecordRequest(context.Background(), nil, "metrics-req", []byte("request"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if got := metrics.GetCounters()["recorder.storage.save.success,type=request"]; got != 1 {
+		t.Fatalf("expected save counter, got %d", got)
+	}
+}
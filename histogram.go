@@ -0,0 +1,188 @@
+package recorder
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	defaultHistogramSamples  = 1024
+	histogramRelativeError   = 0.01
+	histogramMaxSketchBucket = 2048
+)
+
+// HistogramSnapshot summarizes the observations of a histogram series.
+// Quantiles are estimated with a relative error of about one percent.
+type HistogramSnapshot struct {
+	Count uint64
+	Sum   float64
+	Min   float64
+	Max   float64
+	P50   float64
+	P90   float64
+	P99   float64
+}
+
+// histogram keeps exact count/sum/min/max, a log-bucketed quantile sketch with a bounded
+// number of buckets, and a ring of the most recent raw samples.
+type histogram struct {
+	count    uint64
+	sum      float64
+	min      float64
+	max      float64
+	zero     uint64
+	positive map[int]uint64
+	negative map[int]uint64
+
+	sampleRing
+
+	windowStart time.Time
+}
+
+// sampleRing retains the most recent raw observations of a series.
+type sampleRing struct {
+	samples []float64
+	next    int
+	full    bool
+}
+
+func (r *sampleRing) add(value float64) {
+	if cap(r.samples) == 0 {
+		return
+	}
+	if len(r.samples) < cap(r.samples) {
+		r.samples = append(r.samples, value)
+		return
+	}
+	r.samples[r.next] = value
+	r.next = (r.next + 1) % len(r.samples)
+	r.full = true
+}
+
+// recent returns the retained raw samples, oldest first.
+func (r *sampleRing) recent() []float64 {
+	out := make([]float64, 0, len(r.samples))
+	if !r.full {
+		return append(out, r.samples...)
+	}
+	out = append(out, r.samples[r.next:]...)
+	return append(out, r.samples[:r.next]...)
+}
+
+var histogramGamma = (1 + histogramRelativeError) / (1 - histogramRelativeError)
+var histogramLogGamma = math.Log(histogramGamma)
+
+func newHistogram(sampleCap int, now time.Time) *histogram {
+	return &histogram{
+		positive:    make(map[int]uint64),
+		negative:    make(map[int]uint64),
+		sampleRing:  sampleRing{samples: make([]float64, 0, sampleCap)},
+		windowStart: now,
+	}
+}
+
+func (h *histogram) observe(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+	if h.count == 0 || value < h.min {
+		h.min = value
+	}
+	if h.count == 0 || value > h.max {
+		h.max = value
+	}
+	h.count++
+	h.sum += value
+
+	switch {
+	case value > 0:
+		addToSketch(h.positive, value)
+	case value < 0:
+		addToSketch(h.negative, -value)
+	default:
+		h.zero++
+	}
+	h.add(value)
+}
+
+func (h *histogram) reset(now time.Time) {
+	sampleCap := cap(h.samples)
+	*h = *newHistogram(sampleCap, now)
+}
+
+func (h *histogram) snapshot() HistogramSnapshot {
+	if h.count == 0 {
+		return HistogramSnapshot{}
+	}
+	return HistogramSnapshot{
+		Count: h.count,
+		Sum:   h.sum,
+		Min:   h.min,
+		Max:   h.max,
+		P50:   h.quantile(0.50),
+		P90:   h.quantile(0.90),
+		P99:   h.quantile(0.99),
+	}
+}
+
+func (h *histogram) quantile(q float64) float64 {
+	rank := uint64(q * float64(h.count-1))
+	var seen uint64
+
+	negKeys := sortedBucketKeys(h.negative)
+	for i := len(negKeys) - 1; i >= 0; i-- {
+		seen += h.negative[negKeys[i]]
+		if seen > rank {
+			return h.clamp(-bucketValue(negKeys[i]))
+		}
+	}
+	seen += h.zero
+	if seen > rank {
+		return h.clamp(0)
+	}
+	for _, key := range sortedBucketKeys(h.positive) {
+		seen += h.positive[key]
+		if seen > rank {
+			return h.clamp(bucketValue(key))
+		}
+	}
+	return h.max
+}
+
+func (h *histogram) clamp(v float64) float64 {
+	return math.Max(h.min, math.Min(h.max, v))
+}
+
+func addToSketch(buckets map[int]uint64, value float64) {
+	buckets[int(math.Ceil(math.Log(value)/histogramLogGamma))]++
+	if len(buckets) <= histogramMaxSketchBucket {
+		return
+	}
+	// Collapse the two lowest buckets so memory stays bounded; only the accuracy of the
+	// smallest magnitudes degrades.
+	lowest, second := math.MaxInt, math.MaxInt
+	for k := range buckets {
+		switch {
+		case k < lowest:
+			lowest, second = k, lowest
+		case k < second:
+			second = k
+		}
+	}
+	buckets[second] += buckets[lowest]
+	delete(buckets, lowest)
+}
+
+func bucketValue(index int) float64 {
+	return 2 * math.Pow(histogramGamma, float64(index)) / (histogramGamma + 1)
+}
+
+func sortedBucketKeys(buckets map[int]uint64) []int {
+	keys := make([]int, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
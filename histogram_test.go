@@ -0,0 +1,103 @@
+package recorder
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramSnapshotQuantiles(t *testing.T) {
+	m := NewMetrics(WithHistogramSamples(10))
+
+	for i := 1; i <= 1000; i++ {
+		m.RecordHistogram("latency", float64(i), nil)
+	}
+
+	snapshot := m.GetHistogramSnapshots()["latency"]
+	if snapshot.Count != 1000 {
+		t.Fatalf("expected count 1000, got %d", snapshot.Count)
+	}
+	if snapshot.Sum != 500500 {
+		t.Fatalf("expected sum 500500, got %f", snapshot.Sum)
+	}
+	if snapshot.Min != 1 || snapshot.Max != 1000 {
+		t.Fatalf("unexpected min/max: %f/%f", snapshot.Min, snapshot.Max)
+	}
+	for _, tc := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"p50", snapshot.P50, 500},
+		{"p90", snapshot.P90, 900},
+		{"p99", snapshot.P99, 990},
+	} {
+		if math.Abs(tc.got-tc.want)/tc.want > 0.02 {
+			t.Fatalf("%s: expected ~%f, got %f", tc.name, tc.want, tc.got)
+		}
+	}
+
+	samples := m.GetHistograms()["latency"]
+	if len(samples) != 10 {
+		t.Fatalf("expected 10 retained samples, got %d", len(samples))
+	}
+	if samples[0] != 991 || samples[9] != 1000 {
+		t.Fatalf("expected most recent samples oldest first, got %v", samples)
+	}
+}
+
+func TestHistogramSnapshotMixedSigns(t *testing.T) {
+	m := NewMetrics()
+	for _, v := range []float64{-10, -1, 0, 1, 10} {
+		m.RecordHistogram("delta", v, nil)
+	}
+
+	snapshot := m.GetHistogramSnapshots()["delta"]
+	if snapshot.Min != -10 || snapshot.Max != 10 {
+		t.Fatalf("unexpected min/max: %+v", snapshot)
+	}
+	if snapshot.P50 != 0 {
+		t.Fatalf("expected median 0, got %f", snapshot.P50)
+	}
+}
+
+func TestHistogramSketchStaysBounded(t *testing.T) {
+	h := newHistogram(0, time.Now())
+	for i := 0; i < 5000; i++ {
+		h.observe(math.Pow(1.05, float64(i%3000)) * 1e-100)
+	}
+	if len(h.positive) > histogramMaxSketchBucket {
+		t.Fatalf("expected at most %d buckets, got %d", histogramMaxSketchBucket, len(h.positive))
+	}
+	if len(h.samples) != 0 {
+		t.Fatalf("expected no retained samples, got %d", len(h.samples))
+	}
+}
+
+func TestHistogramWindowAndReset(t *testing.T) {
+	current := time.Unix(0, 0)
+	m := NewMetrics(WithHistogramWindow(time.Minute)).(*inMemoryMetrics)
+	m.now = func() time.Time { return current }
+
+	m.RecordHistogram("latency", 5, nil)
+	m.RecordHistogram("latency", 7, nil)
+	if got := m.GetHistogramSnapshots()["latency"].Count; got != 2 {
+		t.Fatalf("expected 2 observations in window, got %d", got)
+	}
+
+	current = current.Add(time.Minute)
+	if got := m.GetHistogramSnapshots()["latency"]; got.Count != 0 {
+		t.Fatalf("expected window to roll over, got %+v", got)
+	}
+
+	m.RecordHistogram("latency", 9, nil)
+	if got := m.GetHistogramSnapshots()["latency"]; got.Count != 1 || got.Max != 9 {
+		t.Fatalf("expected fresh window, got %+v", got)
+	}
+
+	m.IncrementCounter("requests", nil)
+	m.Reset()
+	if len(m.GetCounters()) != 0 || len(m.GetHistogramSnapshots()) != 0 {
+		t.Fatal("expected Reset to clear all metrics")
+	}
+}
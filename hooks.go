@@ -0,0 +1,117 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrSkipRecord can be returned by a BeforeSave hook to drop a record without failing the
+// Record* call.
+var ErrSkipRecord = errors.New("recorder: record skipped")
+
+// Hook intercepts the saves and loads of a recorder built with New. Every field is
+// optional. Lookups such as FindByTag return storage keys rather than records and are
+// not intercepted.
+//
+// BeforeSave hooks run in registration order and may change the record. The first error
+// stops the chain and the save; ErrSkipRecord drops the record silently while any other
+// error is returned to the caller. Record.Payload may share memory with the caller's
+// slice, so replace it rather than modifying it in place.
+//
+// AfterSave and AfterLoad hooks run in reverse registration order, so the first hook
+// registered wraps all others. AfterSave sees the final record and error, including
+// errors returned by BeforeSave; records dropped with ErrSkipRecord never reach it.
+// AfterLoad may replace the loaded data or error.
+type Hook struct {
+	BeforeSave func(ctx context.Context, record *Record) error
+	AfterSave  func(ctx context.Context, record Record, err error)
+	AfterLoad  func(ctx context.Context, recordType RecordType, requestID string, data []byte, err error) ([]byte, error)
+}
+
+// WithHooks appends hooks to the recorder's interceptor chain. Configured scrubbers run
+// before any hook, so hooks only see sanitized records.
+func WithHooks(hooks ...Hook) RecorderOption {
+	return func(o *recorderOptions) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
+
+// ScrubErrorsMetricName counts payloads the payload scrubber failed on, tagged with the
+// record type, including those WithScrubber then scrubbed as plain text.
+const ScrubErrorsMetricName = "scrubber.errors"
+
+// scrubHook applies the payload and tag scrubbers configured with WithPayloadScrubber,
+// WithTagScrubber or WithScrubber, and publishes the scrub reports enabled by audit.
+func (r *baseRecorder) scrubHook(payloadScrubber contentScrubFunc, tagScrubber TagScrubFunc, audit *scrubAuditConfig) Hook {
+	hook := Hook{
+		BeforeSave: func(ctx context.Context, record *Record) error {
+			if payloadScrubber != nil && len(record.Payload) > 0 && !record.valueScrubbed {
+				payload, report, err := payloadScrubber(record.Type, record.ContentType, append([]byte(nil), record.Payload...))
+				if err != nil {
+					r.metrics.IncrementCounter(ScrubErrorsMetricName, map[string]string{"type": string(record.Type)})
+				}
+				var fallback *scrubFallbackError
+				if errors.As(err, &fallback) {
+					r.logger.WithContext(ctx).Warn(
+						"payload could not be scrubbed in its format, scrubbed as plain text",
+						"record_type", record.Type, "content_type", record.ContentType, "error", fallback.err,
+					)
+					err = nil
+				}
+				if err != nil {
+					return fmt.Errorf("scrub %s payload: %w", record.Type, err)
+				}
+				record.Payload, record.scrubReport = payload, report
+			}
+			if tagScrubber != nil && len(record.Tags) > 0 {
+				tags, err := tagScrubber(record.Type, record.Tags)
+				if err != nil {
+					return fmt.Errorf("scrub %s tags: %w", record.Type, err)
+				}
+				record.Tags = tags
+			}
+			if audit.enabled() {
+				audit.applyReport(record)
+			}
+			return nil
+		},
+	}
+	if audit != nil && (audit.metrics || audit.record) {
+		hook.AfterSave = func(ctx context.Context, record Record, err error) {
+			if err == nil {
+				r.publishReport(ctx, audit, record)
+			}
+		}
+	}
+	return hook
+}
+
+func (r *baseRecorder) beforeSave(ctx context.Context, record *Record) error {
+	for _, h := range r.hooks {
+		if h.BeforeSave == nil {
+			continue
+		}
+		if err := h.BeforeSave(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *baseRecorder) afterSave(ctx context.Context, record Record, err error) {
+	for i := len(r.hooks) - 1; i >= 0; i-- {
+		if h := r.hooks[i]; h.AfterSave != nil {
+			h.AfterSave(ctx, record, err)
+		}
+	}
+}
+
+func (r *baseRecorder) afterLoad(ctx context.Context, recordType RecordType, requestID string, data []byte, err error) ([]byte, error) {
+	for i := len(r.hooks) - 1; i >= 0; i-- {
+		if h := r.hooks[i]; h.AfterLoad != nil {
+			data, err = h.AfterLoad(ctx, recordType, requestID, data, err)
+		}
+	}
+	return data, err
+}
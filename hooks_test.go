@@ -0,0 +1,171 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHooksRunInOrderAroundSave(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	var stored Record
+	storage := stubStorage{saveFn: func(_ context.Context, record Record) error {
+		calls = append(calls, "storage")
+		stored = record
+		return nil
+	}}
+
+	hook := func(name string) Hook {
+		return Hook{
+			BeforeSave: func(_ context.Context, record *Record) error {
+				calls = append(calls, "before:"+name)
+				if record.Tags == nil {
+					record.Tags = map[string]string{}
+				}
+				record.Tags[name] = "seen"
+				return nil
+			},
+			AfterSave: func(_ context.Context, record Record, err error) {
+				calls = append(calls, "after:"+name)
+				if err != nil || record.Tags[name] != "seen" {
+					t.Errorf("unexpected AfterSave input: %+v, %v", record, err)
+				}
+			},
+		}
+	}
+
+	rec := New(storage, WithHooks(hook("a"), hook("b")))
+	if err := rec.RecordRequest(ctx, nil, "req", []byte("{}"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	want := []string{"before:a", "before:b", "storage", "after:b", "after:a"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected call order %v, got %v", want, calls)
+	}
+	if !reflect.DeepEqual(stored.Tags, map[string]string{"a": "seen", "b": "seen"}) {
+		t.Fatalf("expected mutated tags to be stored, got %v", stored.Tags)
+	}
+}
+
+func TestHookVetoStopsSave(t *testing.T) {
+	ctx := context.Background()
+	vetoErr := errors.New("missing tenant")
+	var afterErr error
+	var laterCalled bool
+	storage := stubStorage{saveFn: func(context.Context, Record) error {
+		t.Fatal("save should not be called when a hook vetoes the record")
+		return nil
+	}}
+
+	rec := New(storage, WithHooks(
+		Hook{
+			BeforeSave: func(context.Context, *Record) error { return vetoErr },
+			AfterSave:  func(_ context.Context, _ Record, err error) { afterErr = err },
+		},
+		Hook{BeforeSave: func(context.Context, *Record) error {
+			laterCalled = true
+			return nil
+		}},
+	))
+
+	if err := rec.RecordRequest(ctx, nil, "req", []byte("{}"), nil); !errors.Is(err, vetoErr) {
+		t.Fatalf("expected veto error, got %v", err)
+	}
+	if laterCalled {
+		t.Fatal("expected the chain to stop at the first error")
+	}
+	if !errors.Is(afterErr, vetoErr) {
+		t.Fatalf("expected AfterSave to see the veto, got %v", afterErr)
+	}
+}
+
+func TestHookSkipRecord(t *testing.T) {
+	ctx := context.Background()
+	metrics := NewMetrics()
+	storage := stubStorage{saveFn: func(context.Context, Record) error {
+		t.Fatal("save should not be called for skipped records")
+		return nil
+	}}
+
+	rec := New(storage, WithMetrics(metrics), WithCorrelation(), WithHooks(Hook{
+		BeforeSave: func(context.Context, *Record) error { return ErrSkipRecord },
+		AfterSave: func(context.Context, Record, error) {
+			t.Fatal("AfterSave should not be called for skipped records")
+		},
+	}))
+	if err := rec.RecordRequest(ctx, nil, "req", []byte("{}"), nil); err != nil {
+		t.Fatalf("expected skipped record to succeed, got %v", err)
+	}
+	if err := rec.RecordResponse(ctx, nil, "req", []byte("{}"), nil); err != nil {
+		t.Fatalf("expected skipped record to succeed, got %v", err)
+	}
+	if len(metrics.GetCounters()) != 0 {
+		t.Fatalf("expected no metrics for skipped records, got %v", metrics.GetCounters())
+	}
+}
+
+func TestHooksSeeScrubbedRecord(t *testing.T) {
+	ctx := context.Background()
+	var seen Record
+	rec := New(stubStorage{}, WithScrubber(NewScrubber()), WithHooks(Hook{
+		BeforeSave: func(_ context.Context, record *Record) error {
+			seen = *record
+			return nil
+		},
+	}))
+
+	if err := rec.RecordRequest(ctx, nil, "req", []byte(`{"token":"abc"}`), map[string]string{"token": "abc"}); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if string(seen.Payload) == `{"token":"abc"}` || seen.Tags["token"] != "[REDACTED]" {
+		t.Fatalf("expected hook to receive the scrubbed record, got %+v", seen)
+	}
+}
+
+func TestAfterLoadHooks(t *testing.T) {
+	ctx := context.Background()
+	loadErr := errors.New("not found")
+	storage := stubStorage{loadFn: func(_ context.Context, _ RecordType, requestID string) ([]byte, error) {
+		if requestID == "missing" {
+			return nil, loadErr
+		}
+		return []byte("data"), nil
+	}}
+
+	var order []string
+	rec := New(storage, WithHooks(
+		Hook{AfterLoad: func(_ context.Context, _ RecordType, _ string, data []byte, err error) ([]byte, error) {
+			order = append(order, "a")
+			if errors.Is(err, loadErr) {
+				return []byte("fallback"), nil
+			}
+			return data, err
+		}},
+		Hook{AfterLoad: func(_ context.Context, recordType RecordType, _ string, data []byte, err error) ([]byte, error) {
+			order = append(order, "b")
+			if recordType != RecordTypeResponse {
+				t.Errorf("unexpected record type: %s", recordType)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return append(data, '!'), nil
+		}},
+	))
+
+	data, err := rec.GetResponse(ctx, "req")
+	if err != nil || string(data) != "data!" {
+		t.Fatalf("unexpected load result: %q, %v", data, err)
+	}
+	if !reflect.DeepEqual(order, []string{"b", "a"}) {
+		t.Fatalf("expected AfterLoad hooks in reverse order, got %v", order)
+	}
+
+	data, err = rec.GetResponse(ctx, "missing")
+	if err != nil || string(data) != "fallback" {
+		t.Fatalf("expected hook to replace the error, got %q, %v", data, err)
+	}
+}
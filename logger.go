@@ -15,24 +15,54 @@ type Logger interface {
 	WithContext(ctx context.Context) Logger
 }
 
-type slogLogger struct {
-	logger *slog.Logger
-}
+type LoggerOption func(*slogLogger)
 
-func NewLogger(level slog.Level) Logger {
-	opts := &slog.HandlerOptions{
-		Level: level,
+// WithContextExtractors replaces the extractors WithContext uses to turn context values
+// into log attributes. DefaultContextExtractors are used when this option is not given;
+// calling it without extractors disables context attributes.
+func WithContextExtractors(extractors ...ContextExtractor) LoggerOption {
+	return func(l *slogLogger) {
+		l.extractors = append([]ContextExtractor{}, extractors...)
 	}
-	handler := slog.NewJSONHandler(os.Stdout, opts)
-	logger := slog.New(handler)
+}
 
-	return &slogLogger{logger: logger}
+type slogLogger struct {
+	logger     *slog.Logger
+	extractors []ContextExtractor
+}
+
+// NewLogger writes JSON logs at level to os.Stdout.
+func NewLogger(level slog.Level, opts ...LoggerOption) Logger {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+	return NewHandlerLogger(handler, opts...)
 }
 
 func NewDefaultLogger() Logger {
 	return NewLogger(slog.LevelInfo)
 }
 
+// NewSlogLogger wraps an existing *slog.Logger.
+func NewSlogLogger(logger *slog.Logger, opts ...LoggerOption) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	l := &slogLogger{logger: logger}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(l)
+		}
+	}
+	return l
+}
+
+// NewHandlerLogger builds a Logger on top of an slog.Handler.
+func NewHandlerLogger(handler slog.Handler, opts ...LoggerOption) Logger {
+	if handler == nil {
+		return NewSlogLogger(nil, opts...)
+	}
+	return NewSlogLogger(slog.New(handler), opts...)
+}
+
 func (l *slogLogger) Debug(msg string, args ...any) {
 	l.logger.Debug(msg, args...)
 }
@@ -50,11 +80,24 @@ func (l *slogLogger) Error(msg string, args ...any) {
 }
 
 func (l *slogLogger) With(args ...any) Logger {
-	return &slogLogger{logger: l.logger.With(args...)}
+	return &slogLogger{logger: l.logger.With(args...), extractors: l.extractors}
 }
 
 func (l *slogLogger) WithContext(ctx context.Context) Logger {
-	return &slogLogger{logger: l.logger.With("trace_id", getTraceID(ctx))}
+	extractors := l.extractors
+	if extractors == nil {
+		extractors = defaultContextExtractors
+	}
+	var args []any
+	for _, extract := range extractors {
+		if extract != nil {
+			args = append(args, extract(ctx)...)
+		}
+	}
+	if len(args) == 0 {
+		return l
+	}
+	return l.With(args...)
 }
 
 func getTraceID(ctx context.Context) string {
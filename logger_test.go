@@ -51,3 +51,60 @@ func TestNewLoggerConstructors(t *testing.T) {
 		t.Fatal("expected default logger instance")
 	}
 }
+
+func TestHandlerLoggerUsesExtractors(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewHandlerLogger(slog.NewJSONHandler(&buf, nil))
+
+	ctx := ContextWithTenant(ContextWithRequestID(context.Background(), "req-1"), "acme")
+	logger.WithContext(ctx).Info("hello")
+
+	output := buf.String()
+	for _, want := range []string{`"request_id":"req-1"`, `"tenant":"acme"`} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in log output, got %s", want, output)
+		}
+	}
+	if strings.Contains(output, "trace_id") {
+		t.Fatalf("expected missing trace id to be omitted, got %s", output)
+	}
+}
+
+func TestSlogLoggerCustomExtractors(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger := NewSlogLogger(base, WithContextExtractors(func(ctx context.Context) []any {
+		return []any{"custom", "yes"}
+	})).With("component", "test")
+
+	logger.WithContext(ContextWithRequestID(context.Background(), "req-1")).Info("hello")
+
+	output := buf.String()
+	if !strings.Contains(output, `"custom":"yes"`) || !strings.Contains(output, `"component":"test"`) {
+		t.Fatalf("expected custom attributes, got %s", output)
+	}
+	if strings.Contains(output, "request_id") {
+		t.Fatalf("expected default extractors to be replaced, got %s", output)
+	}
+}
+
+func TestStorageLogsCarryRecordFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewHandlerLogger(slog.NewJSONHandler(&buf, nil))
+	storage := stubStorage{saveFn: func(ctx context.Context, record Record) error {
+		logger.WithContext(ctx).Info("saving")
+		return nil
+	}}
+
+	primary := "order-1"
+	if err := New(storage).RecordRequest(context.Background(), &primary, "req-1", []byte("{}"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{`"record_type":"request"`, `"request_id":"req-1"`, `"primary_id":"order-1"`} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %s in storage log, got %s", want, output)
+		}
+	}
+}
@@ -15,20 +15,75 @@ type Metrics interface {
 	GetCounters() map[string]int64
 	GetGauges() map[string]float64
 	GetHistograms() map[string][]float64
+	// GetHistogramSnapshots summarizes every histogram series.
+	GetHistogramSnapshots() map[string]HistogramSnapshot
 }
 
+// SnapshotMetrics is implemented by the Metrics of this module that can be exported with
+// a Reporter. It is kept apart from Metrics so that other implementations need not
+// provide it.
+type SnapshotMetrics interface {
+	Metrics
+	// Snapshot returns every series with its name and tags kept apart.
+	Snapshot() MetricsSnapshot
+	// Reset clears counters and histograms. Gauges hold a current value rather than an
+	// accumulation and are kept.
+	Reset()
+	// SnapshotAndReset returns a Snapshot and resets under the same lock, so nothing
+	// recorded in between is lost.
+	SnapshotAndReset() MetricsSnapshot
+}
+
+type MetricsOption func(*inMemoryMetrics)
+
 type inMemoryMetrics struct {
 	mu         sync.RWMutex
 	counters   map[string]int64
 	gauges     map[string]float64
-	histograms map[string][]float64
+	histograms map[string]*histogram
+	series     map[string]seriesID
+	samples    int
+	window     time.Duration
+	now        func() time.Time
 }
 
-func NewMetrics() Metrics {
-	return &inMemoryMetrics{
+// NewMetrics returns an in-memory Metrics. Histograms use bounded memory: totals and a
+// quantile sketch cover every observation, while GetHistograms only returns the most
+// recent samples (1024 per series by default).
+func NewMetrics(opts ...MetricsOption) SnapshotMetrics {
+	m := &inMemoryMetrics{
 		counters:   make(map[string]int64),
 		gauges:     make(map[string]float64),
-		histograms: make(map[string][]float64),
+		histograms: make(map[string]*histogram),
+		series:     make(map[string]seriesID),
+		samples:    defaultHistogramSamples,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+	return m
+}
+
+// WithHistogramSamples sets how many raw samples per series GetHistograms retains.
+// Zero disables raw sample retention.
+func WithHistogramSamples(n int) MetricsOption {
+	return func(m *inMemoryMetrics) {
+		if n >= 0 {
+			m.samples = n
+		}
+	}
+}
+
+// WithHistogramWindow turns histograms into tumbling windows: a series is cleared once
+// the window has elapsed since its first observation.
+func WithHistogramWindow(window time.Duration) MetricsOption {
+	return func(m *inMemoryMetrics) {
+		if window > 0 {
+			m.window = window
+		}
 	}
 }
 
@@ -36,7 +91,7 @@ func (m *inMemoryMetrics) IncrementCounter(name string, tags map[string]string)
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	key := m.buildKey(name, tags)
+	key := m.track(name, tags)
 	m.counters[key]++
 }
 
@@ -44,7 +99,7 @@ func (m *inMemoryMetrics) SetGauge(name string, value float64, tags map[string]s
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	key := m.buildKey(name, tags)
+	key := m.track(name, tags)
 	m.gauges[key] = value
 }
 
@@ -52,8 +107,15 @@ func (m *inMemoryMetrics) RecordHistogram(name string, value float64, tags map[s
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	key := m.buildKey(name, tags)
-	m.histograms[key] = append(m.histograms[key], value)
+	key := m.track(name, tags)
+	now := m.now()
+	h, ok := m.histograms[key]
+	if !ok {
+		h = newHistogram(m.samples, now)
+		m.histograms[key] = h
+	}
+	m.rotate(h, now)
+	h.observe(value)
 }
 
 func (m *inMemoryMetrics) RecordTiming(name string, duration time.Duration, tags map[string]string) {
@@ -82,19 +144,108 @@ func (m *inMemoryMetrics) GetGauges() map[string]float64 {
 	return result
 }
 
+// GetHistograms returns the most recent raw samples of each series, oldest first.
 func (m *inMemoryMetrics) GetHistograms() map[string][]float64 {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
+	now := m.now()
 	result := make(map[string][]float64)
-	for k, v := range m.histograms {
-		result[k] = make([]float64, len(v))
-		copy(result[k], v)
+	for k, h := range m.histograms {
+		m.rotate(h, now)
+		result[k] = h.recent()
+	}
+	return result
+}
+
+func (m *inMemoryMetrics) GetHistogramSnapshots() map[string]HistogramSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	result := make(map[string]HistogramSnapshot, len(m.histograms))
+	for k, h := range m.histograms {
+		m.rotate(h, now)
+		result[k] = h.snapshot()
 	}
 	return result
 }
 
+func (m *inMemoryMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reset()
+}
+
+// reset keeps gauges, which are overwritten rather than accumulated, and their series.
+func (m *inMemoryMetrics) reset() {
+	series := make(map[string]seriesID, len(m.gauges))
+	for key := range m.gauges {
+		series[key] = m.series[key]
+	}
+	m.counters = make(map[string]int64)
+	m.histograms = make(map[string]*histogram)
+	m.series = series
+}
+
+func (m *inMemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.snapshot()
+}
+
+func (m *inMemoryMetrics) SnapshotAndReset() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.snapshot()
+	m.reset()
+	return snapshot
+}
+
+func (m *inMemoryMetrics) snapshot() MetricsSnapshot {
+	now := m.now()
+	snapshot := MetricsSnapshot{Timestamp: now}
+	for key, value := range m.counters {
+		id := m.series[key]
+		snapshot.Counters = append(snapshot.Counters, CounterSeries{Name: id.name, Tags: cloneTags(id.tags), Value: value})
+	}
+	for key, value := range m.gauges {
+		id := m.series[key]
+		snapshot.Gauges = append(snapshot.Gauges, GaugeSeries{Name: id.name, Tags: cloneTags(id.tags), Value: value})
+	}
+	for key, h := range m.histograms {
+		m.rotate(h, now)
+		id := m.series[key]
+		snapshot.Histograms = append(snapshot.Histograms, HistogramSeries{Name: id.name, Tags: cloneTags(id.tags), HistogramSnapshot: h.snapshot()})
+	}
+	snapshot.sort()
+	return snapshot
+}
+
+// track returns the flattened key for name and tags and remembers them for Snapshot.
+func (m *inMemoryMetrics) track(name string, tags map[string]string) string {
+	key := m.buildKey(name, tags)
+	if _, ok := m.series[key]; !ok {
+		m.series[key] = seriesID{name: name, tags: cloneTags(tags)}
+	}
+	return key
+}
+
+func (m *inMemoryMetrics) rotate(h *histogram, now time.Time) {
+	if m.window > 0 && now.Sub(h.windowStart) >= m.window {
+		h.reset(now)
+	}
+}
+
 func (m *inMemoryMetrics) buildKey(name string, tags map[string]string) string {
+	return metricKey(name, tags)
+}
+
+// metricKey flattens a metric name and its tags into a deterministic "name,k=v" key.
+func metricKey(name string, tags map[string]string) string {
 	if len(tags) == 0 {
 		return name
 	}
@@ -0,0 +1,231 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentTypeMetricsRecord is the content type of the MetricsRecord payloads stored by
+// RecordTypedMetrics.
+const ContentTypeMetricsRecord = "application/vnd.recorder.metrics+json"
+
+// Units of a MetricValue.
+const (
+	UnitMilliseconds = "ms"
+	UnitSeconds      = "s"
+	UnitBytes        = "bytes"
+	UnitCount        = "count"
+	UnitPercent      = "percent"
+)
+
+// legacyUnitSuffixes infer the unit of string metrics stored by RecordMetrics from the
+// suffix of their name.
+var legacyUnitSuffixes = []struct{ suffix, unit string }{
+	{"_ms", UnitMilliseconds},
+	{"_seconds", UnitSeconds},
+	{"_bytes", UnitBytes},
+	{"_count", UnitCount},
+	{"_percent", UnitPercent},
+}
+
+// MetricValue is a single numeric measurement.
+type MetricValue struct {
+	Name      string    `json:"name"`
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DurationMetric returns d as a value in milliseconds.
+func DurationMetric(name string, d time.Duration) MetricValue {
+	return MetricValue{Name: name, Value: float64(d.Nanoseconds()) / 1e6, Unit: UnitMilliseconds}
+}
+
+// CountMetric returns n as a value in UnitCount.
+func CountMetric(name string, n int64) MetricValue {
+	return MetricValue{Name: name, Value: float64(n), Unit: UnitCount}
+}
+
+// MetricsRecord is the JSON document stored by RecordTypedMetrics. Labels hold values that
+// are not numeric, such as the outcome of an exchange.
+type MetricsRecord struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Values    []MetricValue     `json:"values"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// ParseMetricsRecord decodes a metrics payload. Payloads stored by RecordMetrics, a JSON
+// object of strings, are converted: numeric strings become values, with the unit inferred
+// from suffixes such as _ms or _bytes, and any other string becomes a label. Converted
+// records have no timestamps. Values are sorted by name.
+func ParseMetricsRecord(data []byte) (MetricsRecord, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return MetricsRecord{}, fmt.Errorf("decode metrics: %w", err)
+	}
+	legacy := make(map[string]string, len(fields))
+	for name, raw := range fields {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			legacy = nil
+			break
+		}
+		legacy[name] = value
+	}
+	if legacy == nil {
+		var record MetricsRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return MetricsRecord{}, fmt.Errorf("decode metrics: %w", err)
+		}
+		return record, nil
+	}
+
+	var record MetricsRecord
+	for name, value := range legacy {
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			if record.Labels == nil {
+				record.Labels = make(map[string]string)
+			}
+			record.Labels[name] = value
+			continue
+		}
+		record.Values = append(record.Values, MetricValue{Name: name, Value: number, Unit: legacyUnit(name)})
+	}
+	sort.Slice(record.Values, func(i, j int) bool { return record.Values[i].Name < record.Values[j].Name })
+	return record, nil
+}
+
+func legacyUnit(name string) string {
+	for _, s := range legacyUnitSuffixes {
+		if strings.HasSuffix(name, s.suffix) {
+			return s.unit
+		}
+	}
+	return ""
+}
+
+// Value returns the value named name.
+func (m MetricsRecord) Value(name string) (MetricValue, bool) {
+	for _, v := range m.Values {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return MetricValue{}, false
+}
+
+func (m MetricsRecord) validate() error {
+	if len(m.Values) == 0 && len(m.Labels) == 0 {
+		return fmt.Errorf("metrics cannot be nil or empty")
+	}
+	for i, v := range m.Values {
+		if v.Name == "" {
+			return fmt.Errorf("metrics value %d has no name", i)
+		}
+		if math.IsNaN(v.Value) || math.IsInf(v.Value, 0) {
+			return fmt.Errorf("metrics value %q is not finite", v.Name)
+		}
+	}
+	return nil
+}
+
+type metricsForwardConfig struct {
+	tagKeys []string
+}
+
+// WithMetricsForwarding reports every value recorded by RecordTypedMetrics to the
+// recorder's Metrics as well: millisecond and second values with RecordTiming, others
+// with RecordHistogram, under the value's name. Only the record tags listed in tagKeys
+// are passed on, to keep the cardinality of the series bounded.
+func WithMetricsForwarding(tagKeys ...string) RecorderOption {
+	return func(o *recorderOptions) {
+		o.metricsForwarding = &metricsForwardConfig{tagKeys: append([]string(nil), tagKeys...)}
+	}
+}
+
+func (c *metricsForwardConfig) forward(metrics Metrics, record MetricsRecord, recordTags map[string]string) {
+	var tags map[string]string
+	for _, key := range c.tagKeys {
+		if value, ok := recordTags[key]; ok {
+			if tags == nil {
+				tags = make(map[string]string, len(c.tagKeys))
+			}
+			tags[key] = value
+		}
+	}
+	for _, v := range record.Values {
+		switch v.Unit {
+		case UnitMilliseconds:
+			metrics.RecordTiming(v.Name, time.Duration(v.Value*float64(time.Millisecond)), tags)
+		case UnitSeconds:
+			metrics.RecordTiming(v.Name, time.Duration(v.Value*float64(time.Second)), tags)
+		default:
+			metrics.RecordHistogram(v.Name, v.Value, tags)
+		}
+	}
+}
+
+// RecordTypedMetrics stores metrics as a MetricsRecord. A zero record timestamp is set to
+// the current time and zero value timestamps to the record timestamp.
+func (r *baseRecorder) RecordTypedMetrics(ctx context.Context, primaryID *string, requestID string, metrics MetricsRecord, tags map[string]string) error {
+	if requestID == "" {
+		return fmt.Errorf("requestID cannot be empty")
+	}
+	if err := metrics.validate(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if metrics.Timestamp.IsZero() {
+		metrics.Timestamp = now
+	}
+	values := make([]MetricValue, len(metrics.Values))
+	for i, v := range metrics.Values {
+		if v.Timestamp.IsZero() {
+			v.Timestamp = metrics.Timestamp
+		}
+		values[i] = v
+	}
+	metrics.Values = values
+
+	payload, err := json.Marshal(metrics)
+	if err != nil {
+		return fmt.Errorf("cannot marshal metrics: %w", err)
+	}
+	record := Record{
+		Type:        RecordTypeMetrics,
+		PrimaryID:   primaryID,
+		RequestID:   requestID,
+		Payload:     payload,
+		Tags:        r.prepareTags(ctx, tags),
+		Timestamp:   now,
+		ContentType: ContentTypeMetricsRecord,
+	}
+	if saved, err := r.save(ctx, &record); err != nil || !saved {
+		return err
+	}
+	if r.metricsForwarding != nil {
+		r.metricsForwarding.forward(r.metrics, metrics, record.Tags)
+	}
+	return nil
+}
+
+// GetMetrics loads the metrics recorded for requestID with RecordTypedMetrics or
+// RecordMetrics, see ParseMetricsRecord.
+func (r *baseRecorder) GetMetrics(ctx context.Context, requestID string) (MetricsRecord, error) {
+	if requestID == "" {
+		return MetricsRecord{}, fmt.Errorf("requestID cannot be empty")
+	}
+	data, err := r.load(ctx, RecordTypeMetrics, requestID)
+	if err != nil {
+		return MetricsRecord{}, err
+	}
+	return ParseMetricsRecord(data)
+}
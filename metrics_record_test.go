@@ -0,0 +1,127 @@
+package recorder
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordTypedMetricsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	rec := New(storage)
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	in := MetricsRecord{
+		Timestamp: at,
+		Values: []MetricValue{
+			DurationMetric("provider_latency", 1500*time.Microsecond),
+			CountMetric("retries", 2),
+			{Name: "payload_size", Value: 2048, Unit: UnitBytes, Timestamp: at.Add(time.Second)},
+		},
+		Labels: map[string]string{"outcome": "success"},
+	}
+	if err := rec.RecordTypedMetrics(ctx, nil, "req-1", in, nil); err != nil {
+		t.Fatalf("RecordTypedMetrics returned error: %v", err)
+	}
+	if got := storage.records[RecordTypeMetrics]["req-1"].ContentType; got != ContentTypeMetricsRecord {
+		t.Fatalf("unexpected content type %q", got)
+	}
+
+	got, err := rec.GetMetrics(ctx, "req-1")
+	if err != nil {
+		t.Fatalf("GetMetrics returned error: %v", err)
+	}
+	want := MetricsRecord{
+		Timestamp: at,
+		Values: []MetricValue{
+			{Name: "provider_latency", Value: 1.5, Unit: UnitMilliseconds, Timestamp: at},
+			{Name: "retries", Value: 2, Unit: UnitCount, Timestamp: at},
+			{Name: "payload_size", Value: 2048, Unit: UnitBytes, Timestamp: at.Add(time.Second)},
+		},
+		Labels: map[string]string{"outcome": "success"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected metrics\n got %+v\nwant %+v", got, want)
+	}
+	if !in.Values[0].Timestamp.IsZero() {
+		t.Fatal("expected the caller's values to be left untouched")
+	}
+}
+
+func TestGetMetricsReadsLegacyStringMaps(t *testing.T) {
+	ctx := context.Background()
+	rec := New(newMemoryStorage())
+
+	legacy := map[string]string{"duration_ms": "17.5", "response_bytes": "512", "attempts": "3", "outcome": "error"}
+	if err := rec.RecordMetrics(ctx, nil, "req-1", legacy, nil); err != nil {
+		t.Fatalf("RecordMetrics returned error: %v", err)
+	}
+	got, err := rec.GetMetrics(ctx, "req-1")
+	if err != nil {
+		t.Fatalf("GetMetrics returned error: %v", err)
+	}
+	want := MetricsRecord{
+		Values: []MetricValue{
+			{Name: "attempts", Value: 3},
+			{Name: "duration_ms", Value: 17.5, Unit: UnitMilliseconds},
+			{Name: "response_bytes", Value: 512, Unit: UnitBytes},
+		},
+		Labels: map[string]string{"outcome": "error"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected metrics %+v", got)
+	}
+	if v, ok := got.Value("duration_ms"); !ok || v.Value != 17.5 {
+		t.Fatalf("expected duration_ms, got %+v", v)
+	}
+
+	if _, err := ParseMetricsRecord([]byte(`["not", "an", "object"]`)); err == nil {
+		t.Fatal("expected error for a non-object payload")
+	}
+}
+
+func TestRecordTypedMetricsValidation(t *testing.T) {
+	rec := New(newMemoryStorage())
+	ctx := context.Background()
+
+	cases := map[string]MetricsRecord{
+		"empty":   {},
+		"no name": {Values: []MetricValue{{Value: 1}}},
+		"nan":     {Values: []MetricValue{{Name: "x", Value: math.NaN()}}},
+	}
+	for name, metrics := range cases {
+		if err := rec.RecordTypedMetrics(ctx, nil, "req", metrics, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if err := rec.RecordTypedMetrics(ctx, nil, "", MetricsRecord{Values: []MetricValue{CountMetric("x", 1)}}, nil); err == nil {
+		t.Error("expected error for empty requestID")
+	}
+}
+
+func TestMetricsForwarding(t *testing.T) {
+	metrics := NewMetrics()
+	rec := New(newMemoryStorage(), WithMetrics(metrics), WithMetricsForwarding("provider"))
+
+	values := MetricsRecord{Values: []MetricValue{
+		DurationMetric("provider.latency", 250*time.Millisecond),
+		{Name: "provider.latency_seconds", Value: 0.5, Unit: UnitSeconds},
+		CountMetric("provider.retries", 2),
+	}}
+	tags := map[string]string{"provider": "acme", "customer": "c-1"}
+	if err := rec.RecordTypedMetrics(context.Background(), nil, "req-1", values, tags); err != nil {
+		t.Fatalf("RecordTypedMetrics returned error: %v", err)
+	}
+
+	forwarded := map[string]string{"provider": "acme"}
+	histograms := metrics.GetHistograms()
+	for name, want := range map[string]float64{"provider.latency": 250, "provider.latency_seconds": 500, "provider.retries": 2} {
+		got := histograms[metricKey(name, forwarded)]
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("%s: expected [%v], got %v (%v)", name, want, got, histograms)
+		}
+	}
+}
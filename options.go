@@ -4,16 +4,67 @@ type PayloadScrubFunc func(recordType RecordType, payload []byte) ([]byte, error
 
 type TagScrubFunc func(recordType RecordType, tags map[string]string) (map[string]string, error)
 
+// scrubFallbackError reports a payload that could not be scrubbed in its format and was
+// scrubbed as plain text instead. The scrub hook logs and counts it without failing.
+type scrubFallbackError struct {
+	err error
+}
+
+func (e *scrubFallbackError) Error() string {
+	return "scrubbed as plain text: " + e.err.Error()
+}
+
+func (e *scrubFallbackError) Unwrap() error {
+	return e.err
+}
+
+// contentScrubFunc is the internal form of PayloadScrubFunc that also receives the
+// content type of the record, if known, and may return a report of what it changed.
+type contentScrubFunc func(recordType RecordType, contentType string, payload []byte) ([]byte, *ScrubReport, error)
+
+// valueScrubFunc is the internal form of ValueScrubFunc.
+type valueScrubFunc func(recordType RecordType, value any) (any, *ScrubReport, error)
+
 type RecorderOption func(*recorderOptions)
 
 type recorderOptions struct {
-	payloadScrubber PayloadScrubFunc
-	tagScrubber     TagScrubFunc
+	payloadScrubber   contentScrubFunc
+	tagScrubber       TagScrubFunc
+	logger            Logger
+	metrics           Metrics
+	middlewares       []StorageMiddleware
+	correlation       *correlationConfig
+	hooks             []Hook
+	enrichment        tagEnrichment
+	serializer        Serializer
+	valueScrubber     valueScrubFunc
+	scrubAudit        *scrubAuditConfig
+	errorStacks       bool
+	errorClassifiers  []ErrorClassifier
+	metricsForwarding *metricsForwardConfig
+}
+
+func newRecorderOptions(opts ...RecorderOption) recorderOptions {
+	var cfg recorderOptions
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(&cfg)
+	}
+	return cfg
 }
 
 func WithPayloadScrubber(fn PayloadScrubFunc) RecorderOption {
 	return func(o *recorderOptions) {
-		o.payloadScrubber = fn
+		if fn == nil {
+			o.payloadScrubber = nil
+			return
+		}
+		o.payloadScrubber = func(recordType RecordType, _ string, payload []byte) ([]byte, *ScrubReport, error) {
+			payload, err := fn(recordType, payload)
+			return payload, nil, err
+		}
 	}
 }
 
@@ -23,13 +74,65 @@ func WithTagScrubber(fn TagScrubFunc) RecorderOption {
 	}
 }
 
+// WithLogger sets the logger shared by the recorder and the storage built with it.
+func WithLogger(logger Logger) RecorderOption {
+	return func(o *recorderOptions) {
+		o.logger = logger
+	}
+}
+
+// WithMetrics sets the metrics sink shared by the recorder and the storage built with it.
+func WithMetrics(metrics Metrics) RecorderOption {
+	return func(o *recorderOptions) {
+		o.metrics = metrics
+	}
+}
+
+// StorageMiddleware decorates the Storage a recorder persists to.
+type StorageMiddleware func(Storage) Storage
+
+// WithStorageMiddleware wraps the storage handed to New. Middlewares are applied in the
+// order given, so the first one is the outermost and sees every call first.
+func WithStorageMiddleware(middlewares ...StorageMiddleware) RecorderOption {
+	return func(o *recorderOptions) {
+		for _, mw := range middlewares {
+			if mw != nil {
+				o.middlewares = append(o.middlewares, mw)
+			}
+		}
+	}
+}
+
+// Instrumentation returns the logger and metrics configured by opts, falling back to
+// NewDefaultLogger and NewMetrics. Storage constructors use it so that a single
+// WithLogger/WithMetrics reaches both the recorder and the backend.
+func Instrumentation(opts ...RecorderOption) (Logger, Metrics) {
+	cfg := newRecorderOptions(opts...)
+	logger, metrics := cfg.logger, cfg.metrics
+	if logger == nil {
+		logger = NewDefaultLogger()
+	}
+	if metrics == nil {
+		metrics = NewMetrics()
+	}
+	return logger, metrics
+}
+
 type ScrubberBindingOption func(*scrubberBindingConfig)
 
 type scrubberBindingConfig struct {
 	failOnError bool
 	scrubTags   bool
+	contentType string
+	orderedJSON bool
+	audit       scrubAuditConfig
 }
 
+// WithScrubber scrubs the payloads and tags of every record with scrubber. A payload
+// that cannot be decoded in its format, or whose format is unsupported, is scrubbed as
+// plain text with the scrubber's detectors (DefaultDetectors when none are configured)
+// instead of being stored raw. The failure is logged and counted as ScrubErrorsMetricName;
+// ScrubberFailOnError fails the save instead.
 func WithScrubber(scrubber *Scrubber, opts ...ScrubberBindingOption) RecorderOption {
 	return func(o *recorderOptions) {
 		if scrubber == nil {
@@ -42,18 +145,34 @@ func WithScrubber(scrubber *Scrubber, opts ...ScrubberBindingOption) RecorderOpt
 			}
 		}
 		if scrubber != nil {
-			o.payloadScrubber = func(recordType RecordType, payload []byte) ([]byte, error) {
+			// audited returns a per-call copy recording a report when reports are enabled.
+			audited := func() (*Scrubber, *ScrubReport) {
+				if !cfg.audit.enabled() {
+					return scrubber, nil
+				}
+				return scrubber.Audited()
+			}
+			o.scrubAudit = &cfg.audit
+			o.valueScrubber = func(recordType RecordType, value any) (any, *ScrubReport, error) {
+				s, report := audited()
+				return s.scrubTyped(value), report, nil
+			}
+			o.payloadScrubber = func(recordType RecordType, contentType string, payload []byte) ([]byte, *ScrubReport, error) {
 				if len(payload) == 0 {
-					return payload, nil
+					return payload, nil, nil
+				}
+				if contentType == "" {
+					contentType = cfg.contentType
 				}
-				sanitized, err := scrubber.ScrubJSON(payload)
+				s, report := audited()
+				sanitized, err := s.scrubContent(contentType, payload, cfg.orderedJSON)
 				if err != nil {
 					if cfg.failOnError {
-						return nil, err
+						return nil, nil, err
 					}
-					return payload, nil
+					return s.ScrubText(payload), report, &scrubFallbackError{err: err}
 				}
-				return sanitized, nil
+				return sanitized, report, nil
 			}
 			if cfg.scrubTags {
 				o.tagScrubber = func(recordType RecordType, tags map[string]string) (map[string]string, error) {
@@ -64,12 +183,31 @@ func WithScrubber(scrubber *Scrubber, opts ...ScrubberBindingOption) RecorderOpt
 	}
 }
 
+// ScrubberFailOnError fails the save when a payload cannot be scrubbed in its format,
+// instead of falling back to plain-text scrubbing.
 func ScrubberFailOnError() ScrubberBindingOption {
 	return func(cfg *scrubberBindingConfig) {
 		cfg.failOnError = true
 	}
 }
 
+// ScrubberContentType sets the format of payloads recorded without a content type, e.g.
+// ContentTypeXML for a SOAP provider. When unset, the format is sniffed from the payload.
+// Records created by RecordValue always use the content type of their serializer.
+func ScrubberContentType(contentType string) ScrubberBindingOption {
+	return func(cfg *scrubberBindingConfig) {
+		cfg.contentType = contentType
+	}
+}
+
+// ScrubberOrderedJSON scrubs JSON payloads with ScrubJSONOrdered, keeping key order and
+// number literals, instead of ScrubJSON.
+func ScrubberOrderedJSON() ScrubberBindingOption {
+	return func(cfg *scrubberBindingConfig) {
+		cfg.orderedJSON = true
+	}
+}
+
 func ScrubberSkipTags() ScrubberBindingOption {
 	return func(cfg *scrubberBindingConfig) {
 		cfg.scrubTags = false
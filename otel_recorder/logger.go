@@ -0,0 +1,35 @@
+package otel_recorder
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/stremovskyy/recorder"
+)
+
+// NewLogger wraps base so that WithContext also attaches the trace and span IDs of the
+// active OpenTelemetry span.
+func NewLogger(base recorder.Logger) recorder.Logger {
+	if base == nil {
+		base = recorder.NewDefaultLogger()
+	}
+	return &otelLogger{Logger: base}
+}
+
+type otelLogger struct {
+	recorder.Logger
+}
+
+func (l *otelLogger) With(args ...any) recorder.Logger {
+	return &otelLogger{Logger: l.Logger.With(args...)}
+}
+
+func (l *otelLogger) WithContext(ctx context.Context) recorder.Logger {
+	logger := l.Logger.WithContext(ctx)
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return &otelLogger{Logger: logger}
+	}
+	return &otelLogger{Logger: logger.With(TraceIDTag, sc.TraceID().String(), SpanIDTag, sc.SpanID().String())}
+}
@@ -0,0 +1,56 @@
+package otel_recorder
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/stremovskyy/recorder"
+)
+
+type captureLogger struct {
+	args []any
+}
+
+func (l *captureLogger) Debug(string, ...any) {}
+func (l *captureLogger) Info(string, ...any)  {}
+func (l *captureLogger) Warn(string, ...any)  {}
+func (l *captureLogger) Error(string, ...any) {}
+
+func (l *captureLogger) With(args ...any) recorder.Logger {
+	return &captureLogger{args: append(append([]any(nil), l.args...), args...)}
+}
+
+func (l *captureLogger) WithContext(context.Context) recorder.Logger {
+	return l.With("from_base", true)
+}
+
+func TestLoggerWithContextAddsSpanContext(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+
+	logger := NewLogger(&captureLogger{}).With("component", "test").WithContext(ctx)
+	inner := logger.(*otelLogger).Logger.(*captureLogger)
+
+	want := []any{"component", "test", "from_base", true, TraceIDTag, span.SpanContext().TraceID().String(), SpanIDTag, span.SpanContext().SpanID().String()}
+	if len(inner.args) != len(want) {
+		t.Fatalf("expected args %v, got %v", want, inner.args)
+	}
+	for i := range want {
+		if inner.args[i] != want[i] {
+			t.Fatalf("expected args %v, got %v", want, inner.args)
+		}
+	}
+}
+
+func TestLoggerWithContextFallsBackWithoutSpan(t *testing.T) {
+	logger := NewLogger(&captureLogger{}).WithContext(context.Background())
+	inner := logger.(*otelLogger).Logger.(*captureLogger)
+	if len(inner.args) != 2 || inner.args[0] != "from_base" {
+		t.Fatalf("expected base WithContext to be used, got %v", inner.args)
+	}
+}
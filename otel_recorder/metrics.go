@@ -0,0 +1,169 @@
+package otel_recorder
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/stremovskyy/recorder"
+)
+
+// NewMetrics returns a recorder.Metrics that records through an OpenTelemetry Meter.
+// A nil meter uses the global MeterProvider. Values are mirrored into an in-memory
+// recorder.Metrics so the Get* accessors and a recorder.Reporter keep working.
+func NewMetrics(meter metric.Meter) recorder.SnapshotMetrics {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter(instrumentationName)
+	}
+	return &otelMetrics{
+		meter:      meter,
+		local:      recorder.NewMetrics(),
+		counters:   make(map[string]metric.Int64Counter),
+		gauges:     make(map[string]metric.Float64Gauge),
+		histograms: make(map[string]metric.Float64Histogram),
+	}
+}
+
+type otelMetrics struct {
+	meter metric.Meter
+	local recorder.SnapshotMetrics
+
+	mu         sync.Mutex
+	counters   map[string]metric.Int64Counter
+	gauges     map[string]metric.Float64Gauge
+	histograms map[string]metric.Float64Histogram
+}
+
+func (m *otelMetrics) IncrementCounter(name string, tags map[string]string) {
+	m.local.IncrementCounter(name, tags)
+	if counter := m.counter(name); counter != nil {
+		counter.Add(context.Background(), 1, metric.WithAttributes(attributesFor(tags)...))
+	}
+}
+
+func (m *otelMetrics) SetGauge(name string, value float64, tags map[string]string) {
+	m.local.SetGauge(name, value, tags)
+	if gauge := m.gauge(name); gauge != nil {
+		gauge.Record(context.Background(), value, metric.WithAttributes(attributesFor(tags)...))
+	}
+}
+
+func (m *otelMetrics) RecordHistogram(name string, value float64, tags map[string]string) {
+	m.local.RecordHistogram(name, value, tags)
+	if histogram := m.histogram(name, ""); histogram != nil {
+		histogram.Record(context.Background(), value, metric.WithAttributes(attributesFor(tags)...))
+	}
+}
+
+// RecordTiming records the duration in milliseconds, matching recorder.NewMetrics.
+func (m *otelMetrics) RecordTiming(name string, duration time.Duration, tags map[string]string) {
+	m.local.RecordTiming(name, duration, tags)
+	if histogram := m.histogram(name, "ms"); histogram != nil {
+		value := float64(duration.Nanoseconds()) / 1e6
+		histogram.Record(context.Background(), value, metric.WithAttributes(attributesFor(tags)...))
+	}
+}
+
+func (m *otelMetrics) GetCounters() map[string]int64 {
+	return m.local.GetCounters()
+}
+
+func (m *otelMetrics) GetGauges() map[string]float64 {
+	return m.local.GetGauges()
+}
+
+func (m *otelMetrics) GetHistograms() map[string][]float64 {
+	return m.local.GetHistograms()
+}
+
+func (m *otelMetrics) GetHistogramSnapshots() map[string]recorder.HistogramSnapshot {
+	return m.local.GetHistogramSnapshots()
+}
+
+func (m *otelMetrics) Snapshot() recorder.MetricsSnapshot {
+	return m.local.Snapshot()
+}
+
+// Reset clears the local mirror only; OpenTelemetry instruments cannot be reset.
+func (m *otelMetrics) Reset() {
+	m.local.Reset()
+}
+
+// SnapshotAndReset snapshots and clears the local mirror only, see Reset.
+func (m *otelMetrics) SnapshotAndReset() recorder.MetricsSnapshot {
+	return m.local.SnapshotAndReset()
+}
+
+func (m *otelMetrics) counter(name string) metric.Int64Counter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.counters[name]; ok {
+		return c
+	}
+	c, err := m.meter.Int64Counter(name)
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	m.counters[name] = c
+	return c
+}
+
+func (m *otelMetrics) gauge(name string) metric.Float64Gauge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if g, ok := m.gauges[name]; ok {
+		return g
+	}
+	g, err := m.meter.Float64Gauge(name)
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	m.gauges[name] = g
+	return g
+}
+
+func (m *otelMetrics) histogram(name, unit string) metric.Float64Histogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := name + "|" + unit
+	if h, ok := m.histograms[key]; ok {
+		return h
+	}
+	var opts []metric.Float64HistogramOption
+	if unit != "" {
+		opts = append(opts, metric.WithUnit(unit))
+	}
+	h, err := m.meter.Float64Histogram(name, opts...)
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	m.histograms[key] = h
+	return h
+}
+
+func attributesFor(tags map[string]string) []attribute.KeyValue {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String(k, tags[k]))
+	}
+	return attrs
+}
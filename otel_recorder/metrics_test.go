@@ -0,0 +1,63 @@
+package otel_recorder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricsBridgeRecordsThroughMeter(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer provider.Shutdown(context.Background())
+
+	m := NewMetrics(provider.Meter("test"))
+	tags := map[string]string{"prefix": "request"}
+	m.IncrementCounter("redis.record_data.success", tags)
+	m.IncrementCounter("redis.record_data.success", tags)
+	m.SetGauge("redis.pool.idle_conns", 4, nil)
+	m.RecordHistogram("payload.size", 128, tags)
+	m.RecordTiming("redis.record_data.duration", 15*time.Millisecond, tags)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+
+	found := make(map[string]metricdata.Metrics)
+	for _, scope := range rm.ScopeMetrics {
+		for _, metric := range scope.Metrics {
+			found[metric.Name] = metric
+		}
+	}
+
+	counter, ok := found["redis.record_data.success"].Data.(metricdata.Sum[int64])
+	if !ok || len(counter.DataPoints) != 1 || counter.DataPoints[0].Value != 2 {
+		t.Fatalf("unexpected counter data: %+v", found["redis.record_data.success"])
+	}
+	if v, _ := counter.DataPoints[0].Attributes.Value(attribute.Key("prefix")); v.AsString() != "request" {
+		t.Fatalf("expected prefix attribute, got %v", counter.DataPoints[0].Attributes)
+	}
+
+	gauge, ok := found["redis.pool.idle_conns"].Data.(metricdata.Gauge[float64])
+	if !ok || len(gauge.DataPoints) != 1 || gauge.DataPoints[0].Value != 4 {
+		t.Fatalf("unexpected gauge data: %+v", found["redis.pool.idle_conns"])
+	}
+
+	timing := found["redis.record_data.duration"]
+	if timing.Unit != "ms" {
+		t.Fatalf("expected timing unit ms, got %q", timing.Unit)
+	}
+	histogram, ok := timing.Data.(metricdata.Histogram[float64])
+	if !ok || len(histogram.DataPoints) != 1 || histogram.DataPoints[0].Sum != 15 {
+		t.Fatalf("unexpected timing data: %+v", timing)
+	}
+
+	if m.GetCounters()["redis.record_data.success,prefix=request"] != 2 {
+		t.Fatalf("expected local mirror counters, got %v", m.GetCounters())
+	}
+}
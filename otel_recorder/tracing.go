@@ -0,0 +1,158 @@
+package otel_recorder
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/stremovskyy/recorder"
+)
+
+const instrumentationName = "github.com/stremovskyy/recorder/otel_recorder"
+
+const (
+	TraceIDTag = "trace_id"
+	SpanIDTag  = "span_id"
+)
+
+type TracingOption func(*tracingConfig)
+
+type tracingConfig struct {
+	tracerProvider trace.TracerProvider
+	traceTags      bool
+	spanIDTag      bool
+}
+
+// WithTracerProvider selects the provider spans are created with. Defaults to the global provider.
+func WithTracerProvider(provider trace.TracerProvider) TracingOption {
+	return func(cfg *tracingConfig) {
+		cfg.tracerProvider = provider
+	}
+}
+
+// WithoutTraceTags stops Save from adding trace tags to records.
+func WithoutTraceTags() TracingOption {
+	return func(cfg *tracingConfig) {
+		cfg.traceTags = false
+	}
+}
+
+// WithSpanIDTag makes Save add a span_id tag next to trace_id. It is off by default: every
+// save gets a new span ID, so the tag grows the storage tag index by one entry per record.
+func WithSpanIDTag() TracingOption {
+	return func(cfg *tracingConfig) {
+		cfg.spanIDTag = true
+	}
+}
+
+// WithTracing returns a RecorderOption that traces every storage operation of the recorder
+// it is passed to, regardless of the backend.
+func WithTracing(opts ...TracingOption) recorder.RecorderOption {
+	return recorder.WithStorageMiddleware(
+		func(storage recorder.Storage) recorder.Storage {
+			return NewTracedStorage(storage, opts...)
+		},
+	)
+}
+
+// NewTracedStorage wraps storage so that Save, Load and FindByTag run inside spans.
+func NewTracedStorage(storage recorder.Storage, opts ...TracingOption) recorder.Storage {
+	cfg := tracingConfig{traceTags: true}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.tracerProvider == nil {
+		cfg.tracerProvider = otel.GetTracerProvider()
+	}
+	return &tracedStorage{
+		next:      storage,
+		tracer:    cfg.tracerProvider.Tracer(instrumentationName),
+		traceTags: cfg.traceTags,
+		spanIDTag: cfg.spanIDTag,
+	}
+}
+
+type tracedStorage struct {
+	next      recorder.Storage
+	tracer    trace.Tracer
+	traceTags bool
+	spanIDTag bool
+}
+
+func (s *tracedStorage) Save(ctx context.Context, record recorder.Record) error {
+	attrs := []attribute.KeyValue{
+		attribute.String("recorder.record_type", string(record.Type)),
+		attribute.String("recorder.request_id", record.RequestID),
+		attribute.Int("recorder.payload_size", len(record.Payload)),
+	}
+	if record.PrimaryID != nil {
+		attrs = append(attrs, attribute.String("recorder.primary_id", *record.PrimaryID))
+	}
+
+	ctx, span := s.tracer.Start(ctx, "recorder.Save", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	if sc := span.SpanContext(); s.traceTags && sc.IsValid() {
+		tags := make(map[string]string, len(record.Tags)+2)
+		for k, v := range record.Tags {
+			tags[k] = v
+		}
+		tags[TraceIDTag] = sc.TraceID().String()
+		if s.spanIDTag {
+			tags[SpanIDTag] = sc.SpanID().String()
+		}
+		record.Tags = tags
+	}
+
+	err := s.next.Save(ctx, record)
+	finishSpan(span, err)
+	return err
+}
+
+func (s *tracedStorage) Load(ctx context.Context, recordType recorder.RecordType, requestID string) ([]byte, error) {
+	ctx, span := s.tracer.Start(
+		ctx, "recorder.Load",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("recorder.record_type", string(recordType)),
+			attribute.String("recorder.request_id", requestID),
+		),
+	)
+	defer span.End()
+
+	data, err := s.next.Load(ctx, recordType, requestID)
+	if err == nil {
+		span.SetAttributes(attribute.Int("recorder.payload_size", len(data)))
+	}
+	finishSpan(span, err)
+	return data, err
+}
+
+func (s *tracedStorage) FindByTag(ctx context.Context, tag string) ([]string, error) {
+	ctx, span := s.tracer.Start(
+		ctx, "recorder.FindByTag",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("recorder.tag", tag)),
+	)
+	defer span.End()
+
+	results, err := s.next.FindByTag(ctx, tag)
+	if err == nil {
+		span.SetAttributes(attribute.Int("recorder.result_count", len(results)))
+	}
+	finishSpan(span, err)
+	return results, err
+}
+
+func finishSpan(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
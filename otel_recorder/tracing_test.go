@@ -0,0 +1,158 @@
+package otel_recorder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/stremovskyy/recorder"
+	"github.com/stremovskyy/recorder/callback_recorder"
+)
+
+func newTestTracerProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return provider, exporter
+}
+
+func TestWithTracingCreatesSpansAndTraceTags(t *testing.T) {
+	provider, exporter := newTestTracerProvider(t)
+
+	var saved recorder.Record
+	rec := callback_recorder.New(
+		callback_recorder.Options{
+			Save: func(_ context.Context, r recorder.Record) error {
+				saved = r
+				return nil
+			},
+			Load: func(_ context.Context, _ recorder.RecordType, _ string) ([]byte, error) {
+				return nil, errors.New("not found")
+			},
+			Find: func(_ context.Context, _ string) ([]string, error) {
+				return []string{"a", "b"}, nil
+			},
+		},
+		WithTracing(WithTracerProvider(provider)),
+	)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "handler")
+	if err := rec.RecordRequest(ctx, nil, "req-1", []byte("payload"), map[string]string{"env": "dev"}); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if _, err := rec.GetRequest(ctx, "req-1"); err == nil {
+		t.Fatal("expected load error")
+	}
+	if _, err := rec.FindByTag(ctx, "env:dev"); err != nil {
+		t.Fatalf("FindByTag returned error: %v", err)
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+
+	save, ok := byName["recorder.Save"]
+	if !ok {
+		t.Fatalf("expected recorder.Save span, got %v", spans)
+	}
+	if save.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("expected save span to be a child of the caller span")
+	}
+	if !hasAttribute(save.Attributes, attribute.String("recorder.request_id", "req-1")) {
+		t.Fatalf("expected request id attribute, got %v", save.Attributes)
+	}
+	if saved.Tags[TraceIDTag] != save.SpanContext.TraceID().String() {
+		t.Fatalf("expected trace id tag, got %v", saved.Tags)
+	}
+	if _, ok := saved.Tags[SpanIDTag]; ok {
+		t.Fatalf("expected no span id tag by default, got %v", saved.Tags)
+	}
+	if saved.Tags["env"] != "dev" {
+		t.Fatalf("expected original tags to be kept, got %v", saved.Tags)
+	}
+
+	load := byName["recorder.Load"]
+	if load.Status.Code != codes.Error {
+		t.Fatalf("expected load span to record the error, got %v", load.Status)
+	}
+
+	find := byName["recorder.FindByTag"]
+	if !hasAttribute(find.Attributes, attribute.Int("recorder.result_count", 2)) {
+		t.Fatalf("expected result count attribute, got %v", find.Attributes)
+	}
+}
+
+func TestWithoutTraceTags(t *testing.T) {
+	provider, _ := newTestTracerProvider(t)
+
+	var saved recorder.Record
+	storage := NewTracedStorage(
+		recorder.Storage(saveOnly(func(_ context.Context, r recorder.Record) error {
+			saved = r
+			return nil
+		})),
+		WithTracerProvider(provider),
+		WithoutTraceTags(),
+	)
+
+	if err := storage.Save(context.Background(), recorder.Record{Type: recorder.RecordTypeRequest, RequestID: "r", Payload: []byte("x")}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if _, ok := saved.Tags[TraceIDTag]; ok {
+		t.Fatalf("expected no trace tags, got %v", saved.Tags)
+	}
+}
+
+func TestWithSpanIDTag(t *testing.T) {
+	provider, exporter := newTestTracerProvider(t)
+
+	var saved recorder.Record
+	storage := NewTracedStorage(
+		recorder.Storage(saveOnly(func(_ context.Context, r recorder.Record) error {
+			saved = r
+			return nil
+		})),
+		WithTracerProvider(provider),
+		WithSpanIDTag(),
+	)
+
+	if err := storage.Save(context.Background(), recorder.Record{Type: recorder.RecordTypeRequest, RequestID: "r", Payload: []byte("x")}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %v", spans)
+	}
+	if saved.Tags[SpanIDTag] != spans[0].SpanContext.SpanID().String() {
+		t.Fatalf("expected span id tag, got %v", saved.Tags)
+	}
+	if saved.Tags[TraceIDTag] != spans[0].SpanContext.TraceID().String() {
+		t.Fatalf("expected trace id tag, got %v", saved.Tags)
+	}
+}
+
+type saveOnly func(context.Context, recorder.Record) error
+
+func (f saveOnly) Save(ctx context.Context, record recorder.Record) error { return f(ctx, record) }
+
+func (f saveOnly) Load(context.Context, recorder.RecordType, string) ([]byte, error) { return nil, nil }
+
+func (f saveOnly) FindByTag(context.Context, string) ([]string, error) { return nil, nil }
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package recorder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathPattern matches FieldContext paths with wildcards, recursive descent and array
+// indices. Build one with ParsePathPattern.
+type PathPattern struct {
+	source   string
+	segments []pathSegment
+}
+
+type pathSegmentKind int
+
+const (
+	segmentLiteral pathSegmentKind = iota
+	segmentGlob
+	segmentIndex
+	segmentAnyIndex
+	segmentRecursive
+)
+
+type pathSegment struct {
+	kind  pathSegmentKind
+	value string
+}
+
+// ParsePathPattern parses a dotted path pattern. Keys compare case-insensitively and
+// the syntax is a subset of JSONPath:
+//
+//	payment.card.number   exact path
+//	payment.*.number      * matches any single key or index
+//	card_*, tok?n         * and ? inside a key match any run of characters / one character
+//	items[*].card         [*] matches any array index, [2] a specific one
+//	**.cvv, $..cvv        ** and .. match zero or more levels
+//	headers['x.api-key']  quoted keys may contain dots and wildcards, matched literally
+//
+// A leading "$" or "$." is ignored. Array elements appear in paths as "[i]" and XML
+// attributes as "@name".
+func ParsePathPattern(pattern string) (PathPattern, error) {
+	src := strings.TrimSpace(pattern)
+	rest := strings.TrimPrefix(src, "$")
+	if rest == "" {
+		return PathPattern{}, fmt.Errorf("path pattern %q: empty pattern", pattern)
+	}
+
+	var segments []pathSegment
+	add := func(seg pathSegment) {
+		if seg.kind == segmentRecursive && len(segments) > 0 && segments[len(segments)-1].kind == segmentRecursive {
+			return
+		}
+		segments = append(segments, seg)
+	}
+
+	for i := 0; i < len(rest); {
+		switch c := rest[i]; {
+		case c == '.':
+			if i+1 < len(rest) && rest[i+1] == '.' {
+				add(pathSegment{kind: segmentRecursive})
+				i += 2
+				continue
+			}
+			if i+1 == len(rest) || rest[i+1] == '[' {
+				return PathPattern{}, fmt.Errorf("path pattern %q: empty key at offset %d", pattern, i+1)
+			}
+			i++
+		case c == '[':
+			end := strings.IndexByte(rest[i:], ']')
+			if end < 0 {
+				return PathPattern{}, fmt.Errorf("path pattern %q: unclosed '[' at offset %d", pattern, i)
+			}
+			seg, err := parseBracketSegment(rest[i+1 : i+end])
+			if err != nil {
+				return PathPattern{}, fmt.Errorf("path pattern %q: %w", pattern, err)
+			}
+			add(seg)
+			i += end + 1
+		default:
+			end := strings.IndexAny(rest[i:], ".[")
+			if end < 0 {
+				end = len(rest) - i
+			}
+			add(parseKeySegment(strings.TrimSpace(rest[i : i+end])))
+			i += end
+		}
+	}
+	return PathPattern{source: src, segments: segments}, nil
+}
+
+// MustParsePathPattern is like ParsePathPattern but panics on an invalid pattern. It is
+// meant for patterns written as literals in code.
+func MustParsePathPattern(pattern string) PathPattern {
+	p, err := ParsePathPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the pattern as it was written.
+func (p PathPattern) String() string {
+	return p.source
+}
+
+// Match reports whether path matches the pattern.
+func (p PathPattern) Match(path []string) bool {
+	return matchSegments(p.segments, path)
+}
+
+// MatchPaths matches fields whose path matches any of the patterns.
+func MatchPaths(patterns ...PathPattern) FieldMatcher {
+	return func(ctx FieldContext) bool {
+		for _, pattern := range patterns {
+			if pattern.Match(ctx.Path) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchPathGlob is MatchPaths for patterns in the ParsePathPattern syntax. It panics on
+// an invalid pattern; use ParsePathPattern to handle patterns from configuration.
+func MatchPathGlob(patterns ...string) FieldMatcher {
+	parsed := make([]PathPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		parsed = append(parsed, MustParsePathPattern(pattern))
+	}
+	return MatchPaths(parsed...)
+}
+
+func parseBracketSegment(inner string) (pathSegment, error) {
+	inner = strings.TrimSpace(inner)
+	switch {
+	case inner == "*":
+		return pathSegment{kind: segmentAnyIndex}, nil
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		return pathSegment{kind: segmentLiteral, value: inner[1 : len(inner)-1]}, nil
+	}
+	n, err := strconv.Atoi(inner)
+	if err != nil || n < 0 {
+		return pathSegment{}, fmt.Errorf("invalid index [%s]: want [*], [n] or a quoted key", inner)
+	}
+	return pathSegment{kind: segmentIndex, value: "[" + strconv.Itoa(n) + "]"}, nil
+}
+
+func parseKeySegment(key string) pathSegment {
+	switch {
+	case key == "**":
+		return pathSegment{kind: segmentRecursive}
+	case strings.ContainsAny(key, "*?"):
+		return pathSegment{kind: segmentGlob, value: strings.ToLower(key)}
+	default:
+		return pathSegment{kind: segmentLiteral, value: key}
+	}
+}
+
+func matchSegments(segments []pathSegment, path []string) bool {
+	for len(segments) > 0 {
+		seg := segments[0]
+		if seg.kind == segmentRecursive {
+			rest := segments[1:]
+			for skip := 0; skip <= len(path); skip++ {
+				if matchSegments(rest, path[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 || !seg.match(path[0]) {
+			return false
+		}
+		segments, path = segments[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+func (seg pathSegment) match(element string) bool {
+	switch seg.kind {
+	case segmentLiteral:
+		return strings.EqualFold(seg.value, element)
+	case segmentGlob:
+		return wildcardMatch(seg.value, strings.ToLower(element))
+	case segmentIndex:
+		return seg.value == element
+	case segmentAnyIndex:
+		return isIndexElement(element)
+	default:
+		return false
+	}
+}
+
+func isIndexElement(element string) bool {
+	if len(element) < 3 || element[0] != '[' || element[len(element)-1] != ']' {
+		return false
+	}
+	_, err := strconv.Atoi(element[1 : len(element)-1])
+	return err == nil
+}
+
+// wildcardMatch matches s against a pattern where * matches any run of characters and ?
+// a single character.
+func wildcardMatch(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+	pi, si := 0, 0
+	star, mark := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]):
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, si
+			pi++
+		case star >= 0:
+			pi = star + 1
+			mark++
+			si = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
@@ -0,0 +1,106 @@
+package recorder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPathPatternMatch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"payment.card.number", "Payment.Card.Number", true},
+		{"payment.card.number", "payment.card", false},
+		{"payment.*.number", "payment.card.number", true},
+		{"payment.*.number", "payment.card.x.number", false},
+		{"items[*].card.number", "items.[0].card.number", true},
+		{"items[*].card.number", "items.[12].card.number", true},
+		{"items[*].card.number", "items.card.number", false},
+		{"items[1].cvv", "items.[1].cvv", true},
+		{"items[1].cvv", "items.[2].cvv", false},
+		{"items.*", "items.[3]", true},
+		{"**.cvv", "cvv", true},
+		{"**.cvv", "orders.[0].payment.cvv", true},
+		{"$..cvv", "orders.[0].payment.cvv", true},
+		{"$.orders..cvv", "orders.[0].cvv", true},
+		{"$.orders..cvv", "refunds.[0].cvv", false},
+		{"user.**", "user", true},
+		{"user.**", "user.profile.email", true},
+		{"card_*", "CARD_NUMBER", true},
+		{"tok?n", "token", true},
+		{"tok?n", "tokken", false},
+		{"headers['x.api-key']", "headers.x.api-key", false},
+		{"envelope.body.login.@password", "envelope.body.login.@password", true},
+	}
+	for _, tc := range cases {
+		pattern, err := ParsePathPattern(tc.pattern)
+		if err != nil {
+			t.Fatalf("ParsePathPattern(%q) returned error: %v", tc.pattern, err)
+		}
+		if got := pattern.Match(strings.Split(tc.path, ".")); got != tc.want {
+			t.Errorf("%q matching %q = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+
+	quoted := MustParsePathPattern("headers['x.api-key']")
+	if !quoted.Match([]string{"headers", "X.API-KEY"}) {
+		t.Error("expected quoted key to match a key containing dots")
+	}
+}
+
+func TestParsePathPatternErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []string{"", "$", "items[", "items[x]", "items[-1]", "a.", "a.[0]"} {
+		if _, err := ParsePathPattern(pattern); err == nil {
+			t.Errorf("expected %q to be rejected", pattern)
+		}
+	}
+}
+
+func TestMatchPathGlobWithScrubber(t *testing.T) {
+	t.Parallel()
+
+	scrub := NewScrubber(
+		WithoutDefaultRules(),
+		WithRules(
+			NewRule("card", MatchPathGlob("items[*].card.number"), MaskString('*', 0, 4)),
+			NewRule("cvv", MatchPathGlob("**.cvv"), RemoveValue()),
+		),
+	)
+	result := scrub.ScrubMap(map[string]any{
+		"items": []any{
+			map[string]any{"card": map[string]any{"number": "4111111111111111", "cvv": "123"}},
+			map[string]any{"card": map[string]any{"number": "5500000000000004"}},
+		},
+		"card": map[string]any{"number": "keep"},
+	})
+
+	items := result["items"].([]any)
+	first := items[0].(map[string]any)["card"].(map[string]any)
+	second := items[1].(map[string]any)["card"].(map[string]any)
+	if first["number"] != "************1111" || second["number"] != "************0004" {
+		t.Fatalf("expected card numbers to be masked, got %v and %v", first["number"], second["number"])
+	}
+	if first["cvv"] != nil {
+		t.Fatalf("expected nested cvv to be removed, got %v", first["cvv"])
+	}
+	if result["card"].(map[string]any)["number"] != "keep" {
+		t.Fatalf("expected top-level card to be untouched, got %v", result["card"])
+	}
+}
+
+func TestMatchPathGlobPanicsOnInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	MatchPathGlob("items[")
+}
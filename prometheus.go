@@ -0,0 +1,528 @@
+package recorder
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPrometheusBuckets mirrors the Prometheus client default buckets, in seconds.
+var DefaultPrometheusBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type PrometheusOption func(*PrometheusMetrics)
+
+// PrometheusMetrics implements Metrics with Prometheus semantics: tags become labels,
+// histograms are bucketed and the text exposition format is served over HTTP.
+type PrometheusMetrics struct {
+	mu               sync.RWMutex
+	namespace        string
+	constLabels      map[string]string
+	histogramBuckets []float64
+	timingBuckets    []float64
+	counters         map[string]*promCounter
+	gauges           map[string]*promGauge
+	histograms       map[string]*promHistogram
+}
+
+type promCounter struct {
+	family string
+	labels map[string]string
+	value  int64
+}
+
+type promGauge struct {
+	family string
+	labels map[string]string
+	value  float64
+}
+
+type promHistogram struct {
+	family string
+	labels map[string]string
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+	min    float64
+	max    float64
+	sampleRing
+}
+
+var _ SnapshotMetrics = (*PrometheusMetrics)(nil)
+var _ http.Handler = (*PrometheusMetrics)(nil)
+
+func NewPrometheusMetrics(opts ...PrometheusOption) *PrometheusMetrics {
+	m := &PrometheusMetrics{
+		histogramBuckets: DefaultPrometheusBuckets,
+		timingBuckets:    DefaultPrometheusBuckets,
+		counters:         make(map[string]*promCounter),
+		gauges:           make(map[string]*promGauge),
+		histograms:       make(map[string]*promHistogram),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+	return m
+}
+
+// WithPrometheusNamespace prefixes every exported metric name.
+func WithPrometheusNamespace(namespace string) PrometheusOption {
+	return func(m *PrometheusMetrics) {
+		m.namespace = sanitizeMetricName(namespace)
+	}
+}
+
+// WithPrometheusConstLabels adds labels to every exported series.
+func WithPrometheusConstLabels(labels map[string]string) PrometheusOption {
+	return func(m *PrometheusMetrics) {
+		m.constLabels = cloneTags(labels)
+	}
+}
+
+// WithPrometheusHistogramBuckets sets the upper bounds used by RecordHistogram.
+func WithPrometheusHistogramBuckets(buckets ...float64) PrometheusOption {
+	return func(m *PrometheusMetrics) {
+		if normalized := normalizeBuckets(buckets); len(normalized) > 0 {
+			m.histogramBuckets = normalized
+		}
+	}
+}
+
+// WithPrometheusTimingBuckets sets the upper bounds, in seconds, used by RecordTiming.
+func WithPrometheusTimingBuckets(buckets ...float64) PrometheusOption {
+	return func(m *PrometheusMetrics) {
+		if normalized := normalizeBuckets(buckets); len(normalized) > 0 {
+			m.timingBuckets = normalized
+		}
+	}
+}
+
+func (m *PrometheusMetrics) IncrementCounter(name string, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := metricKey(name, tags)
+	c, ok := m.counters[key]
+	if !ok {
+		c = &promCounter{family: m.familyName(name, "_total"), labels: cloneTags(tags)}
+		m.counters[key] = c
+	}
+	c.value++
+}
+
+func (m *PrometheusMetrics) SetGauge(name string, value float64, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := metricKey(name, tags)
+	g, ok := m.gauges[key]
+	if !ok {
+		g = &promGauge{family: m.familyName(name, ""), labels: cloneTags(tags)}
+		m.gauges[key] = g
+	}
+	g.value = value
+}
+
+func (m *PrometheusMetrics) RecordHistogram(name string, value float64, tags map[string]string) {
+	m.observe(m.familyName(name, ""), m.histogramBuckets, value, tags)
+}
+
+// RecordTiming observes the duration in seconds under a "_seconds" suffixed family.
+func (m *PrometheusMetrics) RecordTiming(name string, duration time.Duration, tags map[string]string) {
+	m.observe(m.familyName(name, "_seconds"), m.timingBuckets, duration.Seconds(), tags)
+}
+
+func (m *PrometheusMetrics) observe(family string, bounds []float64, value float64, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := metricKey(family, tags)
+	h, ok := m.histograms[key]
+	if !ok {
+		h = &promHistogram{
+			family:     family,
+			labels:     cloneTags(tags),
+			bounds:     bounds,
+			counts:     make([]uint64, len(bounds)),
+			sampleRing: sampleRing{samples: make([]float64, 0, defaultHistogramSamples)},
+		}
+		m.histograms[key] = h
+	}
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	if h.count == 0 || value < h.min {
+		h.min = value
+	}
+	if h.count == 0 || value > h.max {
+		h.max = value
+	}
+	h.count++
+	h.sum += value
+	h.add(value)
+}
+
+func (h *promHistogram) snapshot() HistogramSnapshot {
+	if h.count == 0 {
+		return HistogramSnapshot{}
+	}
+	return HistogramSnapshot{
+		Count: h.count,
+		Sum:   h.sum,
+		Min:   h.min,
+		Max:   h.max,
+		P50:   h.quantile(0.50),
+		P90:   h.quantile(0.90),
+		P99:   h.quantile(0.99),
+	}
+}
+
+// quantile estimates q by linear interpolation within the bucket holding the rank,
+// the same way Prometheus' histogram_quantile does, clamped to the observed range.
+func (h *promHistogram) quantile(q float64) float64 {
+	rank := q * float64(h.count)
+	var running uint64
+	lower := h.min
+	for i, bound := range h.bounds {
+		prev := running
+		running += h.counts[i]
+		if float64(running) >= rank && h.counts[i] > 0 {
+			upper := math.Min(bound, h.max)
+			fraction := (rank - float64(prev)) / float64(h.counts[i])
+			return math.Max(h.min, lower+(upper-lower)*fraction)
+		}
+		lower = math.Max(bound, h.min)
+	}
+	return h.max
+}
+
+// GetCounters returns counter values keyed by exported family name and labels.
+func (m *PrometheusMetrics) GetCounters() map[string]int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]int64, len(m.counters))
+	for _, c := range m.counters {
+		result[metricKey(c.family, c.labels)] = c.value
+	}
+	return result
+}
+
+// GetGauges returns gauge values keyed by exported family name and labels.
+func (m *PrometheusMetrics) GetGauges() map[string]float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]float64, len(m.gauges))
+	for _, g := range m.gauges {
+		result[metricKey(g.family, g.labels)] = g.value
+	}
+	return result
+}
+
+// GetHistograms returns the most recent raw observations of each series (1024 per series),
+// like the in-memory Metrics, keyed by exported family name and labels. Timings are in
+// seconds under a "_seconds" family. Bucket counts are in GetHistogramSnapshots and the
+// exposition.
+func (m *PrometheusMetrics) GetHistograms() map[string][]float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string][]float64, len(m.histograms))
+	for _, h := range m.histograms {
+		result[metricKey(h.family, h.labels)] = h.recent()
+	}
+	return result
+}
+
+// GetHistogramSnapshots summarizes each series; quantiles are interpolated from the buckets.
+func (m *PrometheusMetrics) GetHistogramSnapshots() map[string]HistogramSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]HistogramSnapshot, len(m.histograms))
+	for _, h := range m.histograms {
+		result[metricKey(h.family, h.labels)] = h.snapshot()
+	}
+	return result
+}
+
+// Snapshot returns every series under its exported family name, including const labels.
+func (m *PrometheusMetrics) Snapshot() MetricsSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.snapshot()
+}
+
+func (m *PrometheusMetrics) snapshot() MetricsSnapshot {
+	snapshot := MetricsSnapshot{Timestamp: time.Now()}
+	for _, c := range m.counters {
+		snapshot.Counters = append(snapshot.Counters, CounterSeries{Name: c.family, Tags: cloneTags(m.mergeLabels(c.labels)), Value: c.value})
+	}
+	for _, g := range m.gauges {
+		snapshot.Gauges = append(snapshot.Gauges, GaugeSeries{Name: g.family, Tags: cloneTags(m.mergeLabels(g.labels)), Value: g.value})
+	}
+	for _, h := range m.histograms {
+		snapshot.Histograms = append(snapshot.Histograms, HistogramSeries{Name: h.family, Tags: cloneTags(m.mergeLabels(h.labels)), HistogramSnapshot: h.snapshot()})
+	}
+	snapshot.sort()
+	return snapshot
+}
+
+// Reset drops every counter and histogram series; scrapers observe it as a counter reset.
+// Gauges are kept.
+func (m *PrometheusMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reset()
+}
+
+// SnapshotAndReset returns a Snapshot and resets atomically.
+func (m *PrometheusMetrics) SnapshotAndReset() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.snapshot()
+	m.reset()
+	return snapshot
+}
+
+func (m *PrometheusMetrics) reset() {
+	m.counters = make(map[string]*promCounter)
+	m.histograms = make(map[string]*promHistogram)
+}
+
+// ServeHTTP writes the current metrics in the Prometheus text exposition format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WriteText(w)
+}
+
+// Handler returns an http.Handler suitable for mounting at /metrics.
+func (m *PrometheusMetrics) Handler() http.Handler {
+	return m
+}
+
+// WriteText writes the current metrics in the Prometheus text exposition format.
+func (m *PrometheusMetrics) WriteText(w io.Writer) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+
+	counters := make(map[string][]*promCounter)
+	for _, c := range m.counters {
+		counters[c.family] = append(counters[c.family], c)
+	}
+	for _, family := range sortedKeys(counters) {
+		writeTypeLine(bw, family, "counter")
+		series := counters[family]
+		sort.Slice(series, func(i, j int) bool { return labelString(series[i].labels) < labelString(series[j].labels) })
+		for _, c := range series {
+			writeSample(bw, family, m.mergeLabels(c.labels), strconv.FormatInt(c.value, 10))
+		}
+	}
+
+	gauges := make(map[string][]*promGauge)
+	for _, g := range m.gauges {
+		gauges[g.family] = append(gauges[g.family], g)
+	}
+	for _, family := range sortedKeys(gauges) {
+		writeTypeLine(bw, family, "gauge")
+		series := gauges[family]
+		sort.Slice(series, func(i, j int) bool { return labelString(series[i].labels) < labelString(series[j].labels) })
+		for _, g := range series {
+			writeSample(bw, family, m.mergeLabels(g.labels), formatFloat(g.value))
+		}
+	}
+
+	histograms := make(map[string][]*promHistogram)
+	for _, h := range m.histograms {
+		histograms[h.family] = append(histograms[h.family], h)
+	}
+	for _, family := range sortedKeys(histograms) {
+		writeTypeLine(bw, family, "histogram")
+		series := histograms[family]
+		sort.Slice(series, func(i, j int) bool { return labelString(series[i].labels) < labelString(series[j].labels) })
+		for _, h := range series {
+			labels := m.mergeLabels(h.labels)
+			var running uint64
+			for i, bound := range h.bounds {
+				running += h.counts[i]
+				writeSample(bw, family+"_bucket", withLabel(labels, "le", formatFloat(bound)), strconv.FormatUint(running, 10))
+			}
+			writeSample(bw, family+"_bucket", withLabel(labels, "le", "+Inf"), strconv.FormatUint(h.count, 10))
+			writeSample(bw, family+"_sum", labels, formatFloat(h.sum))
+			writeSample(bw, family+"_count", labels, strconv.FormatUint(h.count, 10))
+		}
+	}
+
+	return bw.Flush()
+}
+
+func (m *PrometheusMetrics) familyName(name, suffix string) string {
+	family := sanitizeMetricName(name)
+	if m.namespace != "" {
+		family = m.namespace + "_" + family
+	}
+	if suffix != "" && !strings.HasSuffix(family, suffix) {
+		family += suffix
+	}
+	return family
+}
+
+func (m *PrometheusMetrics) mergeLabels(labels map[string]string) map[string]string {
+	if len(m.constLabels) == 0 {
+		return labels
+	}
+	merged := make(map[string]string, len(labels)+len(m.constLabels))
+	for k, v := range m.constLabels {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return merged
+}
+
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	merged := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		merged[k] = v
+	}
+	merged[key] = value
+	return merged
+}
+
+func writeTypeLine(w *bufio.Writer, family, kind string) {
+	w.WriteString("# TYPE ")
+	w.WriteString(family)
+	w.WriteByte(' ')
+	w.WriteString(kind)
+	w.WriteByte('\n')
+}
+
+func writeSample(w *bufio.Writer, name string, labels map[string]string, value string) {
+	w.WriteString(name)
+	w.WriteString(labelString(labels))
+	w.WriteByte(' ')
+	w.WriteString(value)
+	w.WriteByte('\n')
+}
+
+// labelString renders labels as {a="1",b="2"} with sanitized names and escaped values.
+func labelString(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(sanitizeLabelName(k))
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(labels[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func sanitizeMetricName(name string) string {
+	return sanitizeIdentifier(name, true)
+}
+
+func sanitizeLabelName(name string) string {
+	return sanitizeIdentifier(name, false)
+}
+
+func sanitizeIdentifier(name string, allowColon bool) string {
+	if name == "" {
+		return "_"
+	}
+	var b strings.Builder
+	b.Grow(len(name))
+	for i, r := range name {
+		valid := r == '_' ||
+			(r >= 'a' && r <= 'z') ||
+			(r >= 'A' && r <= 'Z') ||
+			(allowColon && r == ':') ||
+			(i > 0 && r >= '0' && r <= '9')
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func escapeLabelValue(value string) string {
+	if !strings.ContainsAny(value, "\\\"\n") {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+func normalizeBuckets(buckets []float64) []float64 {
+	out := make([]float64, 0, len(buckets))
+	for _, b := range buckets {
+		if math.IsNaN(b) || math.IsInf(b, 1) {
+			continue
+		}
+		out = append(out, b)
+	}
+	sort.Float64s(out)
+	deduped := make([]float64, 0, len(out))
+	for _, b := range out {
+		if len(deduped) > 0 && deduped[len(deduped)-1] == b {
+			continue
+		}
+		deduped = append(deduped, b)
+	}
+	return deduped
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
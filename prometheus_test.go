@@ -0,0 +1,123 @@
+package recorder
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetricsExposition(t *testing.T) {
+	m := NewPrometheusMetrics(
+		WithPrometheusNamespace("app"),
+		WithPrometheusConstLabels(map[string]string{"service": "payments"}),
+		WithPrometheusHistogramBuckets(10, 1, 5, 5),
+		WithPrometheusTimingBuckets(0.1, 1),
+	)
+
+	m.IncrementCounter("redis.get_data.errors", map[string]string{"prefix": "request", "error": "get_failed"})
+	m.IncrementCounter("redis.get_data.errors", map[string]string{"error": "get_failed", "prefix": "request"})
+	m.SetGauge("redis.pool.idle_conns", 3, nil)
+	m.RecordHistogram("payload.size", 4, map[string]string{"type": "request"})
+	m.RecordHistogram("payload.size", 50, map[string]string{"type": "request"})
+	m.RecordTiming("redis.get_data.duration", 250*time.Millisecond, map[string]string{"prefix": "request"})
+
+	var b strings.Builder
+	if err := m.WriteText(&b); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	out := b.String()
+
+	expected := []string{
+		"# TYPE app_redis_get_data_errors_total counter",
+		`app_redis_get_data_errors_total{error="get_failed",prefix="request",service="payments"} 2`,
+		"# TYPE app_redis_pool_idle_conns gauge",
+		`app_redis_pool_idle_conns{service="payments"} 3`,
+		"# TYPE app_payload_size histogram",
+		`app_payload_size_bucket{le="1",service="payments",type="request"} 0`,
+		`app_payload_size_bucket{le="5",service="payments",type="request"} 1`,
+		`app_payload_size_bucket{le="10",service="payments",type="request"} 1`,
+		`app_payload_size_bucket{le="+Inf",service="payments",type="request"} 2`,
+		`app_payload_size_sum{service="payments",type="request"} 54`,
+		`app_payload_size_count{service="payments",type="request"} 2`,
+		"# TYPE app_redis_get_data_duration_seconds histogram",
+		`app_redis_get_data_duration_seconds_bucket{le="0.1",prefix="request",service="payments"} 0`,
+		`app_redis_get_data_duration_seconds_bucket{le="1",prefix="request",service="payments"} 1`,
+		`app_redis_get_data_duration_seconds_sum{prefix="request",service="payments"} 0.25`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(out, line+"\n") {
+			t.Fatalf("expected exposition to contain %q, got:\n%s", line, out)
+		}
+	}
+
+	counters := m.GetCounters()
+	if counters["app_redis_get_data_errors_total,error=get_failed,prefix=request"] != 2 {
+		t.Fatalf("unexpected counters: %v", counters)
+	}
+	histograms := m.GetHistograms()
+	if got := histograms["app_payload_size,type=request"]; len(got) != 2 || got[0] != 4 || got[1] != 50 {
+		t.Fatalf("expected raw samples, got %v", got)
+	}
+	if got := histograms["app_redis_get_data_duration_seconds,prefix=request"]; len(got) != 1 || got[0] != 0.25 {
+		t.Fatalf("expected timings in seconds, got %v", got)
+	}
+}
+
+func TestPrometheusMetricsHandler(t *testing.T) {
+	m := NewPrometheusMetrics()
+	m.IncrementCounter("requests", map[string]string{"path": `a"b\c`})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(body), `requests_total{path="a\"b\\c"} 1`) {
+		t.Fatalf("expected escaped label value, got:\n%s", body)
+	}
+}
+
+func TestSanitizeMetricIdentifiers(t *testing.T) {
+	if got := sanitizeMetricName("redis.get-data:1"); got != "redis_get_data:1" {
+		t.Fatalf("unexpected metric name: %s", got)
+	}
+	if got := sanitizeLabelName("9x:y"); got != "_x_y" {
+		t.Fatalf("unexpected label name: %s", got)
+	}
+}
+
+func TestPrometheusMetricsSnapshotsAndReset(t *testing.T) {
+	m := NewPrometheusMetrics(WithPrometheusHistogramBuckets(10, 20, 30, 40))
+	for i := 1; i <= 40; i++ {
+		m.RecordHistogram("size", float64(i), nil)
+	}
+
+	snapshot := m.GetHistogramSnapshots()["size"]
+	if snapshot.Count != 40 || snapshot.Min != 1 || snapshot.Max != 40 {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	if snapshot.P50 != 20 {
+		t.Fatalf("expected interpolated median 20, got %f", snapshot.P50)
+	}
+	if snapshot.P90 != 36 {
+		t.Fatalf("expected interpolated p90 36, got %f", snapshot.P90)
+	}
+
+	m.Reset()
+	if len(m.GetHistogramSnapshots()) != 0 {
+		t.Fatal("expected Reset to drop all series")
+	}
+
+	m.IncrementCounter("requests", nil)
+	taken := m.SnapshotAndReset()
+	if len(taken.Counters) != 1 || taken.Counters[0].Value != 1 {
+		t.Fatalf("unexpected snapshot before reset: %+v", taken)
+	}
+	if len(m.GetCounters()) != 0 {
+		t.Fatal("expected SnapshotAndReset to drop all series")
+	}
+}
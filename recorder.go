@@ -20,8 +20,17 @@ type Recorder interface {
 	RecordResponse(ctx context.Context, primaryID *string, requestID string, response []byte, tags map[string]string) error
 	RecordError(ctx context.Context, id *string, requestID string, err error, tags map[string]string) error
 	RecordMetrics(ctx context.Context, primaryID *string, requestID string, metrics map[string]string, tags map[string]string) error
+	// RecordTypedMetrics stores numeric metrics with units and timestamps as a MetricsRecord.
+	RecordTypedMetrics(ctx context.Context, primaryID *string, requestID string, metrics MetricsRecord, tags map[string]string) error
+	// RecordValue serializes value with the configured Serializer and records it as recordType.
+	RecordValue(ctx context.Context, recordType RecordType, primaryID *string, requestID string, value any, tags map[string]string) error
 	GetRequest(ctx context.Context, requestID string) ([]byte, error)
 	GetResponse(ctx context.Context, requestID string) ([]byte, error)
+	// GetMetrics decodes the metrics of requestID, including those stored by RecordMetrics.
+	GetMetrics(ctx context.Context, requestID string) (MetricsRecord, error)
+	// GetRequestInto and GetResponseInto decode the stored payload into dst with the configured Serializer.
+	GetRequestInto(ctx context.Context, requestID string, dst any) error
+	GetResponseInto(ctx context.Context, requestID string, dst any) error
 	FindByTag(ctx context.Context, tag string) ([]string, error)
 	Async() AsyncRecorder
 }
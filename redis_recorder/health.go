@@ -0,0 +1,66 @@
+package redis_recorder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HealthStatus describes the outcome of a HealthCheck.
+type HealthStatus struct {
+	Latency time.Duration
+	Pool    redis.PoolStats
+}
+
+// HealthCheck pings Redis, reports the round-trip latency and the connection pool statistics,
+// and publishes both as gauges through the recorder metrics. The redis.pool.* gauges are
+// not updated anywhere else, so they are only as fresh as the last HealthCheck.
+func (r *redisRecorder) HealthCheck(ctx context.Context) (HealthStatus, error) {
+	start := time.Now()
+	err := r.client.Ping(ctx).Err()
+	latency := time.Since(start)
+
+	status := HealthStatus{Latency: latency}
+	if stats := r.client.PoolStats(); stats != nil {
+		status.Pool = *stats
+	}
+	r.reportPoolStats(status.Pool)
+	r.metrics.RecordTiming("redis.health_check.duration", latency, nil)
+
+	if err != nil {
+		r.metrics.IncrementCounter("redis.health_check.errors", nil)
+		r.logger.WithContext(ctx).Error("redis health check failed", "error", err)
+		return status, fmt.Errorf("redis health check failed: %w", err)
+	}
+	return status, nil
+}
+
+// Close releases the underlying Redis client and its connection pool.
+func (r *redisRecorder) Close() error {
+	return r.client.Close()
+}
+
+func (r *redisRecorder) ping(ctx context.Context) error {
+	timeout := r.options.DialTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis recorder: ping %s failed: %w", r.options.Addr, err)
+	}
+	return nil
+}
+
+func (r *redisRecorder) reportPoolStats(stats redis.PoolStats) {
+	r.metrics.SetGauge("redis.pool.hits", float64(stats.Hits), nil)
+	r.metrics.SetGauge("redis.pool.misses", float64(stats.Misses), nil)
+	r.metrics.SetGauge("redis.pool.timeouts", float64(stats.Timeouts), nil)
+	r.metrics.SetGauge("redis.pool.total_conns", float64(stats.TotalConns), nil)
+	r.metrics.SetGauge("redis.pool.idle_conns", float64(stats.IdleConns), nil)
+	r.metrics.SetGauge("redis.pool.stale_conns", float64(stats.StaleConns), nil)
+}
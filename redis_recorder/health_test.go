@@ -0,0 +1,70 @@
+package redis_recorder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisRecorderHealthCheck(t *testing.T) {
+	storage, _, mr := newTestRedisRecorder(t)
+	ctx := context.Background()
+
+	status, err := storage.HealthCheck(ctx)
+	if err != nil {
+		t.Fatalf("HealthCheck returned error: %v", err)
+	}
+	if status.Latency <= 0 {
+		t.Fatalf("expected positive latency, got %s", status.Latency)
+	}
+	if status.Pool.TotalConns == 0 {
+		t.Fatalf("expected pool stats to report connections, got %+v", status.Pool)
+	}
+
+	gauges := storage.metrics.GetGauges()
+	if gauges["redis.pool.total_conns"] != float64(status.Pool.TotalConns) {
+		t.Fatalf("expected pool gauge %d, got %v", status.Pool.TotalConns, gauges)
+	}
+	if _, ok := storage.metrics.GetHistograms()["redis.health_check.duration"]; !ok {
+		t.Fatal("expected health check duration to be recorded")
+	}
+
+	mr.Close()
+	if _, err := storage.HealthCheck(ctx); err == nil {
+		t.Fatal("expected health check to fail when redis is down")
+	}
+	if storage.metrics.GetCounters()["redis.health_check.errors"] != 1 {
+		t.Fatalf("expected health check error counter, got %v", storage.metrics.GetCounters())
+	}
+}
+
+func TestNewRedisRecorderPingOnStartup(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	rec, err := NewRedisRecorderWithValidation(&Options{Addr: mr.Addr(), PingOnStartup: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if _, err := rec.HealthCheck(context.Background()); err == nil {
+		t.Fatal("expected health check to fail on a closed recorder")
+	}
+
+	addr := mr.Addr()
+	mr.Close()
+	_, err = NewRedisRecorderWithValidation(
+		&Options{
+			Addr:          addr,
+			PingOnStartup: true,
+			MaxRetries:    -1,
+			DialTimeout:   100 * time.Millisecond,
+		},
+	)
+	if err == nil {
+		t.Fatal("expected startup ping to fail for unreachable redis")
+	}
+}
@@ -26,6 +26,14 @@ type Options struct {
 	MaxConnAge      time.Duration
 	PoolTimeout     time.Duration
 	IdleTimeout     time.Duration
+	// PingOnStartup makes NewRedisRecorderWithValidation fail fast when Redis is unreachable.
+	PingOnStartup bool
+	// StreamEnabled publishes a StreamEvent to StreamName on every Save. Publishing is
+	// best effort: a failed XADD is logged and counted as redis.stream.errors, and Save
+	// still succeeds because the record itself was stored.
+	StreamEnabled bool
+	StreamName    string
+	StreamMaxLen  int64
 }
 
 func NewDefaultOptions(addr string, password string, DB int) *Options {
@@ -70,6 +78,10 @@ func NewOptionsFromEnv() *Options {
 		PoolTimeout:     getEnvDurationOrDefault("REDIS_POOL_TIMEOUT", 4*time.Second),
 		IdleTimeout:     getEnvDurationOrDefault("REDIS_IDLE_TIMEOUT", 5*time.Minute),
 		Debug:           getEnvBoolOrDefault("REDIS_DEBUG", false),
+		PingOnStartup:   getEnvBoolOrDefault("REDIS_PING_ON_STARTUP", false),
+		StreamEnabled:   getEnvBoolOrDefault("REDIS_STREAM_ENABLED", false),
+		StreamName:      os.Getenv("REDIS_STREAM_NAME"),
+		StreamMaxLen:    int64(getEnvIntOrDefault("REDIS_STREAM_MAX_LEN", 0)),
 	}
 	return opts
 }
@@ -87,6 +99,9 @@ func (o *Options) Validate() error {
 	if o.Prefix == "" {
 		return fmt.Errorf("prefix cannot be empty")
 	}
+	if o.StreamMaxLen < 0 {
+		return fmt.Errorf("stream max length cannot be negative")
+	}
 	if o.PoolSize <= 0 {
 		o.PoolSize = 10 // default pool size
 	}
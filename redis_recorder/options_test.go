@@ -23,6 +23,11 @@ func TestOptionsValidate(t *testing.T) {
 	if o.PoolSize != 10 {
 		t.Fatalf("expected pool size default of 10, got %d", o.PoolSize)
 	}
+
+	o = &Options{Addr: "localhost:6379", DefaultTTL: time.Second, CompressionLvl: 1, Prefix: "test", StreamMaxLen: -1}
+	if err := o.Validate(); err == nil {
+		t.Fatal("expected error for negative stream max length")
+	}
 }
 
 func TestNewOptionsFromEnv(t *testing.T) {
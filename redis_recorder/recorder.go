@@ -6,7 +6,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"sync"
 	"time"
 
@@ -16,13 +15,54 @@ import (
 )
 
 const (
-	RequestPrefix  = "request"
-	ResponsePrefix = "response"
-	ErrorPrefix    = "error"
-	MetricsPrefix  = "metrics"
-	TagsPrefix     = "tag"
+	RequestPrefix     = "request"
+	ResponsePrefix    = "response"
+	ErrorPrefix       = "error"
+	MetricsPrefix     = "metrics"
+	ScrubReportPrefix = "scrub_report"
+	TagsPrefix        = "tag"
+	TimeIndexPrefix   = "tindex"
+	StreamSuffix      = "stream"
 )
 
+// RedisRecorder extends recorder.Recorder with queries that are specific to the Redis backend.
+type RedisRecorder interface {
+	recorder.Recorder
+	// FindByTagBetween returns the keys of records of the given type indexed under tag whose
+	// timestamps fall within [from, to], oldest first. A zero bound leaves that side open.
+	FindByTagBetween(ctx context.Context, recordType recorder.RecordType, tag string, from, to time.Time) ([]string, error)
+	// FindLatestByTag returns up to limit keys of records of the given type indexed under tag,
+	// newest first.
+	FindLatestByTag(ctx context.Context, recordType recorder.RecordType, tag string, limit int64) ([]string, error)
+	// HealthCheck pings Redis and reports latency and connection pool statistics. The
+	// redis.pool.* gauges are only updated by HealthCheck, so call it periodically to
+	// keep them current.
+	HealthCheck(ctx context.Context) (HealthStatus, error)
+	// Close releases the underlying Redis client.
+	Close() error
+}
+
+type redisRecorderHandle struct {
+	recorder.Recorder
+	storage *redisRecorder
+}
+
+func (h *redisRecorderHandle) FindByTagBetween(ctx context.Context, recordType recorder.RecordType, tag string, from, to time.Time) ([]string, error) {
+	return h.storage.FindByTagBetween(ctx, recordType, tag, from, to)
+}
+
+func (h *redisRecorderHandle) FindLatestByTag(ctx context.Context, recordType recorder.RecordType, tag string, limit int64) ([]string, error) {
+	return h.storage.FindLatestByTag(ctx, recordType, tag, limit)
+}
+
+func (h *redisRecorderHandle) HealthCheck(ctx context.Context) (HealthStatus, error) {
+	return h.storage.HealthCheck(ctx)
+}
+
+func (h *redisRecorderHandle) Close() error {
+	return h.storage.Close()
+}
+
 type redisRecorder struct {
 	client     *redis.Client
 	options    *Options
@@ -96,16 +136,20 @@ func (c *compressor) decompressData(compressedData []byte) ([]byte, error) {
 	return decompressedData, nil
 }
 
-func NewRedisRecorder(options *Options, recorderOpts ...recorder.RecorderOption) recorder.Recorder {
+// NewRedisRecorder is NewRedisRecorderWithValidation without the error: invalid options or a
+// failed startup ping are logged through the recorder's Logger (see recorder.WithLogger)
+// and nil is returned. Use NewRedisRecorderWithValidation to handle the error.
+func NewRedisRecorder(options *Options, recorderOpts ...recorder.RecorderOption) RedisRecorder {
 	rec, err := NewRedisRecorderWithValidation(options, recorderOpts...)
 	if err != nil {
-		log.Printf("failed to create redis recorder: %v", err)
+		logger, _ := recorder.Instrumentation(recorderOpts...)
+		logger.With("component", "redis_recorder").Error("failed to create redis recorder", "error", err)
 		return nil
 	}
 	return rec
 }
 
-func NewRedisRecorderWithValidation(options *Options, recorderOpts ...recorder.RecorderOption) (recorder.Recorder, error) {
+func NewRedisRecorderWithValidation(options *Options, recorderOpts ...recorder.RecorderOption) (RedisRecorder, error) {
 	if options == nil {
 		return nil, fmt.Errorf("redis recorder: options must not be nil")
 	}
@@ -136,11 +180,8 @@ func NewRedisRecorderWithValidation(options *Options, recorderOpts ...recorder.R
 		},
 	)
 
-	// Only validate connection for the new function, not backward compatible one
-	// Skip ping test for backward compatibility - let it fail at runtime if needed
-
-	logger := recorder.NewDefaultLogger().With("component", "redis_recorder")
-	metrics := recorder.NewMetrics()
+	logger, metrics := recorder.Instrumentation(recorderOpts...)
+	logger = logger.With("component", "redis_recorder")
 
 	storage := &redisRecorder{
 		client:     client,
@@ -150,7 +191,17 @@ func NewRedisRecorderWithValidation(options *Options, recorderOpts ...recorder.R
 		metrics:    metrics,
 	}
 
-	return recorder.New(storage, recorderOpts...), nil
+	if cfg.PingOnStartup {
+		if err := storage.ping(context.Background()); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+
+	return &redisRecorderHandle{
+		Recorder: recorder.New(storage, recorderOpts...),
+		storage:  storage,
+	}, nil
 }
 
 func applyRedisDefaults(options *Options) {
@@ -193,6 +244,12 @@ func applyRedisDefaults(options *Options) {
 	if options.MaxConnAge == 0 {
 		options.MaxConnAge = 30 * time.Minute
 	}
+	if options.StreamEnabled && options.StreamName == "" {
+		options.StreamName = fmt.Sprintf("%s:%s", options.Prefix, StreamSuffix)
+	}
+	if options.StreamEnabled && options.StreamMaxLen == 0 {
+		options.StreamMaxLen = 10000
+	}
 }
 
 func (r *redisRecorder) Save(ctx context.Context, record recorder.Record) error {
@@ -225,7 +282,21 @@ func (r *redisRecorder) Save(ctx context.Context, record recorder.Record) error
 	tags["request_id"] = id
 	tags["record_type"] = prefix
 
-	return r.recordData(ctx, prefix, id, compressedData, tags)
+	timestamp := record.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	if err := r.recordData(ctx, prefix, id, compressedData, tags, timestamp); err != nil {
+		return err
+	}
+	if !r.options.StreamEnabled {
+		return nil
+	}
+	// The record is stored at this point; failing the Save would make callers retry a
+	// write that succeeded. publishEvent logs and counts the failure instead.
+	_ = r.publishEvent(ctx, record, r.itemKey(prefix, id), tags, timestamp)
+	return nil
 }
 
 func (r *redisRecorder) Load(ctx context.Context, recordType recorder.RecordType, requestID string) ([]byte, error) {
@@ -264,18 +335,20 @@ func (r *redisRecorder) prefixFor(recordType recorder.RecordType) (string, error
 		return ErrorPrefix, nil
 	case recorder.RecordTypeMetrics:
 		return MetricsPrefix, nil
+	case recorder.RecordTypeScrubReport:
+		return ScrubReportPrefix, nil
 	default:
 		return "", fmt.Errorf("unknown record type: %s", recordType)
 	}
 }
 
-func (r *redisRecorder) recordData(ctx context.Context, prefix, id string, compressedData []byte, tags map[string]string) error {
+func (r *redisRecorder) recordData(ctx context.Context, prefix, id string, compressedData []byte, tags map[string]string, timestamp time.Time) error {
 	start := time.Now()
 	defer func() {
 		r.metrics.RecordTiming("redis.record_data.duration", time.Since(start), map[string]string{"prefix": prefix})
 	}()
 
-	key := fmt.Sprintf("%s:%s:%s", r.options.Prefix, prefix, id)
+	key := r.itemKey(prefix, id)
 	logger := r.logger.WithContext(ctx).With("prefix", prefix, "key", key)
 
 	if r.options.Debug {
@@ -290,7 +363,10 @@ func (r *redisRecorder) recordData(ctx context.Context, prefix, id string, compr
 
 	r.metrics.IncrementCounter("redis.record_data.success", map[string]string{"prefix": prefix})
 	logger.Debug("data recorded successfully")
-	return r.updateTagIndex(ctx, tags, key)
+	if err := r.updateTagIndex(ctx, tags, key); err != nil {
+		return err
+	}
+	return r.updateTimeIndex(ctx, prefix, tags, key, timestamp)
 }
 
 func (r *redisRecorder) getData(ctx context.Context, prefix, id string) ([]byte, error) {
@@ -299,7 +375,7 @@ func (r *redisRecorder) getData(ctx context.Context, prefix, id string) ([]byte,
 		r.metrics.RecordTiming("redis.get_data.duration", time.Since(start), map[string]string{"prefix": prefix})
 	}()
 
-	key := fmt.Sprintf("%s:%s:%s", r.options.Prefix, prefix, id)
+	key := r.itemKey(prefix, id)
 	logger := r.logger.WithContext(ctx).With("prefix", prefix, "key", key)
 
 	data, err := r.client.Get(ctx, key).Result()
@@ -326,6 +402,10 @@ func (r *redisRecorder) getData(ctx context.Context, prefix, id string) ([]byte,
 	return decompressedData, nil
 }
 
+func (r *redisRecorder) itemKey(prefix, id string) string {
+	return fmt.Sprintf("%s:%s:%s", r.options.Prefix, prefix, id)
+}
+
 func (r *redisRecorder) updateTagIndex(ctx context.Context, tags map[string]string, itemKey string) error {
 	for key, value := range tags {
 		tagKey := fmt.Sprintf("%s:%s:%s:%s", r.options.Prefix, TagsPrefix, key, value)
@@ -1,10 +1,13 @@
 package redis_recorder
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
@@ -131,6 +134,15 @@ func TestRedisRecorderRoundTrip(t *testing.T) {
 		t.Fatalf("expected record_type:response to include %s, got %v", expectedResponseKey, recordTypeTags)
 	}
 
+	errorClassTags, err := rec.FindByTag(ctx, recorder.ErrorClassTag+":"+recorder.ErrorClassUnknown)
+	if err != nil {
+		t.Fatalf("FindByTag returned error: %v", err)
+	}
+	expectedErrorKey := fmt.Sprintf("%s:%s:%s", storage.options.Prefix, ErrorPrefix, "req1")
+	if !contains(errorClassTags, expectedErrorKey) {
+		t.Fatalf("expected error_class:unknown to include %s, got %v", expectedErrorKey, errorClassTags)
+	}
+
 	requestIDTags, err := rec.FindByTag(ctx, "request_id:req1")
 	if err != nil {
 		t.Fatalf("FindByTag returned error: %v", err)
@@ -226,7 +238,36 @@ func TestNewRedisRecorderWithValidationErrors(t *testing.T) {
 }
 
 func TestNewRedisRecorderGracefulFailure(t *testing.T) {
-	if rec := NewRedisRecorder(nil); rec != nil {
+	var buf bytes.Buffer
+	logger := recorder.NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	if rec := NewRedisRecorder(nil, recorder.WithLogger(logger)); rec != nil {
 		t.Fatal("expected nil recorder when options invalid")
 	}
+	if !strings.Contains(buf.String(), "failed to create redis recorder") || !strings.Contains(buf.String(), "component=redis_recorder") {
+		t.Fatalf("expected the error to be logged through the recorder logger, got %q", buf.String())
+	}
+}
+
+func TestNewRedisRecorderUsesInjectedMetrics(t *testing.T) {
+	mr := miniredis.RunT(t)
+	metrics := recorder.NewMetrics()
+
+	rec, err := NewRedisRecorderWithValidation(&Options{Addr: mr.Addr()}, recorder.WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rec.Close()
+
+	if err := rec.RecordRequest(context.Background(), nil, "req1", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	counters := metrics.GetCounters()
+	if counters["redis.record_data.success,prefix=request"] != 1 {
+		t.Fatalf("expected redis counters in injected metrics, got %v", counters)
+	}
+	if counters["recorder.storage.save.success,type=request"] != 1 {
+		t.Fatalf("expected recorder counters in injected metrics, got %v", counters)
+	}
 }
@@ -0,0 +1,354 @@
+package redis_recorder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/stremovskyy/recorder"
+)
+
+// StreamEvent is the lightweight notification published to the change feed on every Save.
+// It references the stored record by Key rather than carrying the payload.
+type StreamEvent struct {
+	ID        string
+	Type      recorder.RecordType
+	RequestID string
+	PrimaryID string
+	Key       string
+	Tags      map[string]string
+	Timestamp time.Time
+}
+
+func (e StreamEvent) values() (map[string]any, error) {
+	tags, err := json.Marshal(e.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"type":       string(e.Type),
+		"request_id": e.RequestID,
+		"primary_id": e.PrimaryID,
+		"key":        e.Key,
+		"tags":       string(tags),
+		"timestamp":  strconv.FormatInt(e.Timestamp.UnixMilli(), 10),
+	}, nil
+}
+
+func parseStreamEvent(msg redis.XMessage) (StreamEvent, error) {
+	event := StreamEvent{
+		ID:        msg.ID,
+		Type:      recorder.RecordType(streamValue(msg.Values, "type")),
+		RequestID: streamValue(msg.Values, "request_id"),
+		PrimaryID: streamValue(msg.Values, "primary_id"),
+		Key:       streamValue(msg.Values, "key"),
+	}
+	if raw := streamValue(msg.Values, "tags"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &event.Tags); err != nil {
+			return StreamEvent{}, fmt.Errorf("decode stream event %s tags: %w", msg.ID, err)
+		}
+	}
+	if raw := streamValue(msg.Values, "timestamp"); raw != "" {
+		millis, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return StreamEvent{}, fmt.Errorf("decode stream event %s timestamp: %w", msg.ID, err)
+		}
+		event.Timestamp = time.UnixMilli(millis)
+	}
+	return event, nil
+}
+
+func streamValue(values map[string]any, key string) string {
+	if v, ok := values[key]; ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func (r *redisRecorder) publishEvent(ctx context.Context, record recorder.Record, key string, tags map[string]string, timestamp time.Time) error {
+	event := StreamEvent{
+		Type:      record.Type,
+		RequestID: record.RequestID,
+		Key:       key,
+		Tags:      tags,
+		Timestamp: timestamp,
+	}
+	if record.PrimaryID != nil {
+		event.PrimaryID = *record.PrimaryID
+	}
+
+	values, err := event.values()
+	if err != nil {
+		return fmt.Errorf("failed to encode stream event: %w", err)
+	}
+
+	logger := r.logger.WithContext(ctx).With("stream", r.options.StreamName, "key", key)
+
+	err = r.client.XAdd(
+		ctx, &redis.XAddArgs{
+			Stream: r.options.StreamName,
+			MaxLen: r.options.StreamMaxLen,
+			Approx: true,
+			Values: values,
+		},
+	).Err()
+	if err != nil {
+		r.metrics.IncrementCounter("redis.stream.errors", map[string]string{"operation": "xadd"})
+		logger.Error("failed to publish stream event", "error", err)
+		return fmt.Errorf("failed to publish stream event for key %s: %w", key, err)
+	}
+
+	r.metrics.IncrementCounter("redis.stream.published", map[string]string{"type": string(record.Type)})
+	return nil
+}
+
+// StreamConsumerOptions configures a StreamConsumer.
+type StreamConsumerOptions struct {
+	// Stream is the stream name, usually Options.StreamName.
+	Stream string
+	// Group is the consumer group shared by cooperating consumers.
+	Group string
+	// Consumer uniquely names this consumer within the group.
+	Consumer string
+	// Count caps the number of events returned per read. Defaults to 10.
+	Count int64
+	// Block is how long a read waits for new events. Defaults to 5 seconds.
+	Block time.Duration
+	// StartID is where a newly created group starts reading. Defaults to "$" (new events only).
+	StartID string
+	// OnInvalid is called for every entry that cannot be decoded into a StreamEvent, and
+	// when dead-lettering or acknowledging such an entry fails. Invalid entries are
+	// skipped either way, so the rest of the batch is still returned.
+	OnInvalid func(ctx context.Context, id string, err error)
+	// DeadLetterStream, when set, receives a copy of every invalid entry, with its original
+	// ID under "source_id" and the decode error under "error", before it is acknowledged.
+	DeadLetterStream string
+	// AckInvalid acknowledges invalid entries so they leave the pending list. It is
+	// implied by DeadLetterStream.
+	AckInvalid bool
+	// ReclaimIdle is how long an event stays pending, e.g. after the handler rejected it or
+	// its consumer died, before Run claims it for redelivery. Defaults to one minute; a
+	// negative value disables reclaiming in Run.
+	ReclaimIdle time.Duration
+	// OnError is called by Run for every event the handler rejects. The event stays
+	// pending and is redelivered after ReclaimIdle.
+	OnError func(ctx context.Context, event StreamEvent, err error)
+}
+
+// StreamConsumer tails the change feed through a Redis consumer group.
+type StreamConsumer struct {
+	client redis.Cmdable
+	opts   StreamConsumerOptions
+
+	// mu guards cursor, where the next Reclaim continues scanning the pending list.
+	mu     sync.Mutex
+	cursor string
+}
+
+// NewStreamConsumer builds a consumer for the change feed using the supplied Redis client.
+func NewStreamConsumer(client redis.Cmdable, opts StreamConsumerOptions) (*StreamConsumer, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis stream consumer: client must not be nil")
+	}
+	if opts.Stream == "" {
+		return nil, fmt.Errorf("redis stream consumer: stream cannot be empty")
+	}
+	if opts.Group == "" {
+		return nil, fmt.Errorf("redis stream consumer: group cannot be empty")
+	}
+	if opts.Consumer == "" {
+		return nil, fmt.Errorf("redis stream consumer: consumer cannot be empty")
+	}
+	if opts.Count <= 0 {
+		opts.Count = 10
+	}
+	if opts.Block <= 0 {
+		opts.Block = 5 * time.Second
+	}
+	if opts.StartID == "" {
+		opts.StartID = "$"
+	}
+	if opts.ReclaimIdle == 0 {
+		opts.ReclaimIdle = time.Minute
+	}
+	return &StreamConsumer{client: client, opts: opts, cursor: "0-0"}, nil
+}
+
+// EnsureGroup creates the consumer group (and the stream) if it does not exist yet.
+func (c *StreamConsumer) EnsureGroup(ctx context.Context) error {
+	err := c.client.XGroupCreateMkStream(ctx, c.opts.Stream, c.opts.Group, c.opts.StartID).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s: %w", c.opts.Group, err)
+	}
+	return nil
+}
+
+// Read returns the next batch of events delivered to this consumer. It blocks for at most
+// the configured Block duration and returns an empty slice when nothing arrived.
+func (c *StreamConsumer) Read(ctx context.Context) ([]StreamEvent, error) {
+	streams, err := c.client.XReadGroup(
+		ctx, &redis.XReadGroupArgs{
+			Group:    c.opts.Group,
+			Consumer: c.opts.Consumer,
+			Streams:  []string{c.opts.Stream, ">"},
+			Count:    c.opts.Count,
+			Block:    c.opts.Block,
+		},
+	).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read stream %s: %w", c.opts.Stream, err)
+	}
+
+	var events []StreamEvent
+	for _, stream := range streams {
+		events = append(events, c.parseMessages(ctx, stream.Messages)...)
+	}
+	return events, nil
+}
+
+// Reclaim claims up to Count events that have been pending in the group for at least
+// ReclaimIdle, whichever consumer they were delivered to, and returns them for
+// redelivery. Successive calls walk the whole pending list.
+func (c *StreamConsumer) Reclaim(ctx context.Context) ([]StreamEvent, error) {
+	minIdle := c.opts.ReclaimIdle
+	if minIdle < 0 {
+		minIdle = 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msgs, next, err := c.client.XAutoClaim(
+		ctx, &redis.XAutoClaimArgs{
+			Stream:   c.opts.Stream,
+			Group:    c.opts.Group,
+			Consumer: c.opts.Consumer,
+			MinIdle:  minIdle,
+			Start:    c.cursor,
+			Count:    c.opts.Count,
+		},
+	).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reclaim stream %s: %w", c.opts.Stream, err)
+	}
+	c.cursor = next
+	return c.parseMessages(ctx, msgs), nil
+}
+
+// parseMessages decodes msgs, handing entries that cannot be decoded to handleInvalid.
+func (c *StreamConsumer) parseMessages(ctx context.Context, msgs []redis.XMessage) []StreamEvent {
+	var events []StreamEvent
+	for _, msg := range msgs {
+		event, err := parseStreamEvent(msg)
+		if err != nil {
+			c.handleInvalid(ctx, msg, err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// handleInvalid reports an entry that could not be decoded and, if configured, moves it to
+// the dead-letter stream and acknowledges it.
+func (c *StreamConsumer) handleInvalid(ctx context.Context, msg redis.XMessage, decodeErr error) {
+	report := func(err error) {
+		if c.opts.OnInvalid != nil {
+			c.opts.OnInvalid(ctx, msg.ID, err)
+		}
+	}
+	report(decodeErr)
+
+	if c.opts.DeadLetterStream != "" {
+		values := make(map[string]any, len(msg.Values)+2)
+		for k, v := range msg.Values {
+			values[k] = v
+		}
+		values["source_id"] = msg.ID
+		values["error"] = decodeErr.Error()
+		if err := c.client.XAdd(ctx, &redis.XAddArgs{Stream: c.opts.DeadLetterStream, Values: values}).Err(); err != nil {
+			report(fmt.Errorf("failed to dead-letter stream event %s: %w", msg.ID, err))
+			return
+		}
+	} else if !c.opts.AckInvalid {
+		return
+	}
+	if err := c.Ack(ctx, msg.ID); err != nil {
+		report(err)
+	}
+}
+
+// Ack acknowledges processed events so they leave the group's pending list.
+func (c *StreamConsumer) Ack(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := c.client.XAck(ctx, c.opts.Stream, c.opts.Group, ids...).Err(); err != nil {
+		return fmt.Errorf("failed to ack stream events: %w", err)
+	}
+	return nil
+}
+
+// Run reads events until ctx is cancelled, acknowledging each one the handler processes
+// without error. Events the handler rejects are reported to OnError and stay pending;
+// before each read, Run reclaims events pending for longer than ReclaimIdle and hands them
+// to the handler again. Entries that cannot be decoded are handled as described by
+// OnInvalid and do not stop Run.
+func (c *StreamConsumer) Run(ctx context.Context, handler func(ctx context.Context, event StreamEvent) error) error {
+	if handler == nil {
+		return fmt.Errorf("redis stream consumer: handler must not be nil")
+	}
+	if err := c.EnsureGroup(ctx); err != nil {
+		return err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var events []StreamEvent
+		if c.opts.ReclaimIdle > 0 {
+			reclaimed, err := c.Reclaim(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return err
+			}
+			events = reclaimed
+		}
+		fresh, err := c.Read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		events = append(events, fresh...)
+
+		for _, event := range events {
+			if err := handler(ctx, event); err != nil {
+				if c.opts.OnError != nil {
+					c.opts.OnError(ctx, event, err)
+				}
+				continue
+			}
+			if err := c.Ack(ctx, event.ID); err != nil {
+				return err
+			}
+		}
+	}
+}
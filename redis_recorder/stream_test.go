@@ -0,0 +1,326 @@
+package redis_recorder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/stremovskyy/recorder"
+)
+
+func enableTestStream(storage *redisRecorder) {
+	storage.options.StreamEnabled = true
+	storage.options.StreamName = storage.options.Prefix + ":" + StreamSuffix
+	storage.options.StreamMaxLen = 100
+}
+
+func TestRedisRecorderPublishesStreamEvents(t *testing.T) {
+	storage, rec, _ := newTestRedisRecorder(t)
+	enableTestStream(storage)
+	ctx := context.Background()
+
+	consumer, err := NewStreamConsumer(
+		storage.client, StreamConsumerOptions{
+			Stream:   storage.options.StreamName,
+			Group:    "audit",
+			Consumer: "c1",
+			Block:    10 * time.Millisecond,
+			StartID:  "0",
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewStreamConsumer returned error: %v", err)
+	}
+	if err := consumer.EnsureGroup(ctx); err != nil {
+		t.Fatalf("EnsureGroup returned error: %v", err)
+	}
+	if err := consumer.EnsureGroup(ctx); err != nil {
+		t.Fatalf("EnsureGroup should be idempotent, got: %v", err)
+	}
+
+	primary := "order-7"
+	if err := rec.RecordRequest(ctx, &primary, "req1", []byte("payload"), map[string]string{"env": "dev"}); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	events, err := consumer.Read(ctx)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Type != recorder.RecordTypeRequest || event.RequestID != "req1" || event.PrimaryID != primary {
+		t.Fatalf("unexpected event identity: %+v", event)
+	}
+	if event.Key != storage.itemKey(RequestPrefix, primary+":req1") {
+		t.Fatalf("unexpected event key: %s", event.Key)
+	}
+	if event.Tags["env"] != "dev" || event.Tags["record_type"] != RequestPrefix {
+		t.Fatalf("unexpected event tags: %+v", event.Tags)
+	}
+	if event.Timestamp.IsZero() {
+		t.Fatal("expected event timestamp to be set")
+	}
+
+	if err := consumer.Ack(ctx, event.ID); err != nil {
+		t.Fatalf("Ack returned error: %v", err)
+	}
+	pending, err := storage.client.XPending(ctx, storage.options.StreamName, "audit").Result()
+	if err != nil {
+		t.Fatalf("XPending returned error: %v", err)
+	}
+	if pending.Count != 0 {
+		t.Fatalf("expected no pending events after ack, got %d", pending.Count)
+	}
+
+	more, err := consumer.Read(ctx)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if len(more) != 0 {
+		t.Fatalf("expected no further events, got %v", more)
+	}
+}
+
+func TestStreamConsumerRunAcksHandledEvents(t *testing.T) {
+	storage, rec, _ := newTestRedisRecorder(t)
+	enableTestStream(storage)
+
+	consumer, err := NewStreamConsumer(
+		storage.client, StreamConsumerOptions{
+			Stream:   storage.options.StreamName,
+			Group:    "workers",
+			Consumer: "w1",
+			Block:    10 * time.Millisecond,
+			StartID:  "0",
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewStreamConsumer returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := rec.RecordRequest(ctx, nil, "ok", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if err := rec.RecordRequest(ctx, nil, "fail", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	err = consumer.Run(
+		ctx, func(_ context.Context, event StreamEvent) error {
+			seen[event.RequestID] = true
+			if len(seen) == 2 {
+				cancel()
+			}
+			if event.RequestID == "fail" {
+				return errors.New("handler failed")
+			}
+			return nil
+		},
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context cancellation, got %v", err)
+	}
+	if !seen["ok"] || !seen["fail"] {
+		t.Fatalf("expected both events to be handled, got %v", seen)
+	}
+
+	pending, err := storage.client.XPending(context.Background(), storage.options.StreamName, "workers").Result()
+	if err != nil {
+		t.Fatalf("XPending returned error: %v", err)
+	}
+	if pending.Count != 1 {
+		t.Fatalf("expected rejected event to stay pending, got %d", pending.Count)
+	}
+}
+
+func TestNewStreamConsumerValidation(t *testing.T) {
+	storage, _, _ := newTestRedisRecorder(t)
+
+	if _, err := NewStreamConsumer(nil, StreamConsumerOptions{Stream: "s", Group: "g", Consumer: "c"}); err == nil {
+		t.Fatal("expected error for nil client")
+	}
+	if _, err := NewStreamConsumer(storage.client, StreamConsumerOptions{Group: "g", Consumer: "c"}); err == nil {
+		t.Fatal("expected error for missing stream")
+	}
+	if _, err := NewStreamConsumer(storage.client, StreamConsumerOptions{Stream: "s", Consumer: "c"}); err == nil {
+		t.Fatal("expected error for missing group")
+	}
+	if _, err := NewStreamConsumer(storage.client, StreamConsumerOptions{Stream: "s", Group: "g"}); err == nil {
+		t.Fatal("expected error for missing consumer")
+	}
+}
+
+func TestStreamConsumerSkipsInvalidEvents(t *testing.T) {
+	storage, rec, _ := newTestRedisRecorder(t)
+	enableTestStream(storage)
+	deadLetter := storage.options.StreamName + ":dead"
+
+	var invalid []string
+	consumer, err := NewStreamConsumer(
+		storage.client, StreamConsumerOptions{
+			Stream:           storage.options.StreamName,
+			Group:            "workers",
+			Consumer:         "w1",
+			Block:            10 * time.Millisecond,
+			StartID:          "0",
+			DeadLetterStream: deadLetter,
+			OnInvalid: func(_ context.Context, id string, err error) {
+				invalid = append(invalid, id)
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewStreamConsumer returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := rec.RecordRequest(ctx, nil, "before", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	badID, err := storage.client.XAdd(
+		ctx, &redis.XAddArgs{
+			Stream: storage.options.StreamName,
+			Values: map[string]any{"type": "request", "tags": "{bad"},
+		},
+	).Result()
+	if err != nil {
+		t.Fatalf("XAdd returned error: %v", err)
+	}
+	if err := rec.RecordRequest(ctx, nil, "after", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	err = consumer.Run(
+		ctx, func(_ context.Context, event StreamEvent) error {
+			seen[event.RequestID] = true
+			if len(seen) == 2 {
+				cancel()
+			}
+			return nil
+		},
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context cancellation, got %v", err)
+	}
+	if !seen["before"] || !seen["after"] {
+		t.Fatalf("expected events around the invalid one to be handled, got %v", seen)
+	}
+	if len(invalid) != 1 || invalid[0] != badID {
+		t.Fatalf("expected invalid event %s to be reported, got %v", badID, invalid)
+	}
+
+	pending, err := storage.client.XPendingExt(
+		context.Background(), &redis.XPendingExtArgs{
+			Stream: storage.options.StreamName,
+			Group:  "workers",
+			Start:  "-",
+			End:    "+",
+			Count:  10,
+		},
+	).Result()
+	if err != nil {
+		t.Fatalf("XPendingExt returned error: %v", err)
+	}
+	for _, p := range pending {
+		if p.ID == badID {
+			t.Fatalf("expected invalid event %s to be acknowledged", badID)
+		}
+	}
+
+	dead, err := storage.client.XRange(context.Background(), deadLetter, "-", "+").Result()
+	if err != nil {
+		t.Fatalf("XRange returned error: %v", err)
+	}
+	if len(dead) != 1 || dead[0].Values["source_id"] != badID || dead[0].Values["tags"] != "{bad" {
+		t.Fatalf("unexpected dead-letter entries: %+v", dead)
+	}
+	if dead[0].Values["error"] == "" {
+		t.Fatal("expected dead-letter entry to carry the decode error")
+	}
+}
+
+func TestRedisRecorderSaveSucceedsWhenPublishFails(t *testing.T) {
+	storage, rec, _ := newTestRedisRecorder(t)
+	enableTestStream(storage)
+	ctx := context.Background()
+
+	// A plain string under the stream name makes XADD fail with WRONGTYPE.
+	if err := storage.client.Set(ctx, storage.options.StreamName, "not a stream", 0).Err(); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	if err := rec.RecordRequest(ctx, nil, "req1", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest should succeed when publishing fails, got: %v", err)
+	}
+	data, err := rec.GetRequest(ctx, "req1")
+	if err != nil {
+		t.Fatalf("GetRequest returned error: %v", err)
+	}
+	if string(data) != "payload" {
+		t.Fatalf("unexpected payload: %q", data)
+	}
+}
+
+func TestStreamConsumerRunRedeliversRejectedEvents(t *testing.T) {
+	storage, rec, _ := newTestRedisRecorder(t)
+	enableTestStream(storage)
+
+	var rejected []string
+	consumer, err := NewStreamConsumer(
+		storage.client, StreamConsumerOptions{
+			Stream:      storage.options.StreamName,
+			Group:       "workers",
+			Consumer:    "w1",
+			Block:       10 * time.Millisecond,
+			StartID:     "0",
+			ReclaimIdle: time.Millisecond,
+			OnError: func(_ context.Context, event StreamEvent, err error) {
+				rejected = append(rejected, event.RequestID+": "+err.Error())
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewStreamConsumer returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := rec.RecordRequest(ctx, nil, "flaky", []byte("payload"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	attempts := 0
+	err = consumer.Run(
+		ctx, func(_ context.Context, event StreamEvent) error {
+			attempts++
+			if attempts == 1 {
+				return errors.New("temporarily unavailable")
+			}
+			cancel()
+			return nil
+		},
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context cancellation, got %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected the rejected event to be redelivered once, got %d attempts", attempts)
+	}
+	if len(rejected) != 1 || rejected[0] != "flaky: temporarily unavailable" {
+		t.Fatalf("expected the handler error to be reported, got %v", rejected)
+	}
+}
@@ -0,0 +1,103 @@
+package redis_recorder
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/stremovskyy/recorder"
+)
+
+// FindByTagBetween returns the keys of records of the given type indexed under tag whose
+// timestamps fall within [from, to], oldest first. A zero bound leaves that side open.
+func (r *redisRecorder) FindByTagBetween(ctx context.Context, recordType recorder.RecordType, tag string, from, to time.Time) ([]string, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("tag cannot be empty")
+	}
+	prefix, err := r.prefixFor(recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := r.client.ZRangeByScore(
+		ctx, r.timeIndexKey(prefix, tag), &redis.ZRangeBy{
+			Min: scoreBound(from, "-inf"),
+			Max: scoreBound(to, "+inf"),
+		},
+	).Result()
+	if err != nil {
+		r.metrics.IncrementCounter("redis.time_index.errors", map[string]string{"operation": "zrangebyscore"})
+		return nil, fmt.Errorf("failed to find by tag in range: %w", err)
+	}
+	return keys, nil
+}
+
+// FindLatestByTag returns up to limit keys of records of the given type indexed under tag,
+// newest first. A non-positive limit returns every indexed key.
+func (r *redisRecorder) FindLatestByTag(ctx context.Context, recordType recorder.RecordType, tag string, limit int64) ([]string, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("tag cannot be empty")
+	}
+	prefix, err := r.prefixFor(recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := int64(-1)
+	if limit > 0 {
+		stop = limit - 1
+	}
+
+	keys, err := r.client.ZRevRange(ctx, r.timeIndexKey(prefix, tag), 0, stop).Result()
+	if err != nil {
+		r.metrics.IncrementCounter("redis.time_index.errors", map[string]string{"operation": "zrevrange"})
+		return nil, fmt.Errorf("failed to find latest by tag: %w", err)
+	}
+	return keys, nil
+}
+
+// updateTimeIndex adds itemKey to a sorted set per tag scored by the record timestamp and
+// trims entries that are older than the configured TTL.
+func (r *redisRecorder) updateTimeIndex(ctx context.Context, prefix string, tags map[string]string, itemKey string, timestamp time.Time) error {
+	score := float64(timestamp.UnixMilli())
+	cutoff := strconv.FormatInt(time.Now().Add(-r.options.DefaultTTL).UnixMilli(), 10)
+
+	for key, value := range tags {
+		indexKey := r.timeIndexKey(prefix, fmt.Sprintf("%s:%s", key, value))
+		logger := r.logger.WithContext(ctx).With("index_key", indexKey, "index_value", itemKey)
+
+		if r.options.Debug {
+			logger.Debug("updating time index")
+		}
+
+		_, err := r.client.Pipelined(
+			ctx, func(pipe redis.Pipeliner) error {
+				pipe.ZAdd(ctx, indexKey, redis.Z{Score: score, Member: itemKey})
+				pipe.ZRemRangeByScore(ctx, indexKey, "-inf", "("+cutoff)
+				pipe.Expire(ctx, indexKey, r.options.DefaultTTL)
+				return nil
+			},
+		)
+		if err != nil {
+			r.metrics.IncrementCounter("redis.time_index.errors", map[string]string{"operation": "zadd"})
+			logger.Error("failed to update time index", "error", err)
+			return fmt.Errorf("failed to update time index for key %s: %w", indexKey, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *redisRecorder) timeIndexKey(prefix, tag string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", r.options.Prefix, TimeIndexPrefix, prefix, tag)
+}
+
+func scoreBound(t time.Time, open string) string {
+	if t.IsZero() {
+		return open
+	}
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
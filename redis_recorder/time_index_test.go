@@ -0,0 +1,120 @@
+package redis_recorder
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stremovskyy/recorder"
+)
+
+func TestRedisRecorderTimeIndexRangeAndLatest(t *testing.T) {
+	storage, _, _ := newTestRedisRecorder(t)
+	ctx := context.Background()
+
+	base := time.Now().Add(-10 * time.Minute)
+	for i, id := range []string{"e1", "e2", "e3"} {
+		err := storage.Save(
+			ctx, recorder.Record{
+				Type:      recorder.RecordTypeError,
+				RequestID: id,
+				Payload:   []byte("boom"),
+				Tags:      map[string]string{"provider": "x"},
+				Timestamp: base.Add(time.Duration(i) * time.Minute),
+			},
+		)
+		if err != nil {
+			t.Fatalf("Save returned error: %v", err)
+		}
+	}
+	if err := storage.Save(
+		ctx, recorder.Record{
+			Type:      recorder.RecordTypeRequest,
+			RequestID: "r1",
+			Payload:   []byte("req"),
+			Tags:      map[string]string{"provider": "x"},
+			Timestamp: base,
+		},
+	); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	keyFor := func(id string) string {
+		return fmt.Sprintf("%s:%s:%s", storage.options.Prefix, ErrorPrefix, id)
+	}
+
+	inRange, err := storage.FindByTagBetween(ctx, recorder.RecordTypeError, "provider:x", base.Add(30*time.Second), time.Time{})
+	if err != nil {
+		t.Fatalf("FindByTagBetween returned error: %v", err)
+	}
+	if want := []string{keyFor("e2"), keyFor("e3")}; !reflect.DeepEqual(inRange, want) {
+		t.Fatalf("expected %v, got %v", want, inRange)
+	}
+
+	latest, err := storage.FindLatestByTag(ctx, recorder.RecordTypeError, "provider:x", 2)
+	if err != nil {
+		t.Fatalf("FindLatestByTag returned error: %v", err)
+	}
+	if want := []string{keyFor("e3"), keyFor("e2")}; !reflect.DeepEqual(latest, want) {
+		t.Fatalf("expected %v, got %v", want, latest)
+	}
+
+	all, err := storage.FindLatestByTag(ctx, recorder.RecordTypeError, "provider:x", 0)
+	if err != nil {
+		t.Fatalf("FindLatestByTag returned error: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected error index to hold 3 entries, got %v", all)
+	}
+}
+
+func TestRedisRecorderTimeIndexTrimsExpiredEntries(t *testing.T) {
+	storage, _, mr := newTestRedisRecorder(t)
+	ctx := context.Background()
+
+	save := func(id string, ts time.Time) {
+		t.Helper()
+		err := storage.Save(
+			ctx, recorder.Record{
+				Type:      recorder.RecordTypeRequest,
+				RequestID: id,
+				Payload:   []byte("req"),
+				Tags:      map[string]string{"env": "dev"},
+				Timestamp: ts,
+			},
+		)
+		if err != nil {
+			t.Fatalf("Save returned error: %v", err)
+		}
+	}
+
+	save("old", time.Now().Add(-2*storage.options.DefaultTTL))
+	save("new", time.Now())
+
+	keys, err := storage.FindLatestByTag(ctx, recorder.RecordTypeRequest, "env:dev", 0)
+	if err != nil {
+		t.Fatalf("FindLatestByTag returned error: %v", err)
+	}
+	want := []string{fmt.Sprintf("%s:%s:%s", storage.options.Prefix, RequestPrefix, "new")}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("expected trimmed index %v, got %v", want, keys)
+	}
+
+	if ttl := mr.TTL(storage.timeIndexKey(RequestPrefix, "env:dev")); ttl != storage.options.DefaultTTL {
+		t.Fatalf("expected time index ttl %s, got %s", storage.options.DefaultTTL, ttl)
+	}
+}
+
+func TestRedisRecorderTimeIndexValidation(t *testing.T) {
+	storage, _, _ := newTestRedisRecorder(t)
+	ctx := context.Background()
+
+	if _, err := storage.FindByTagBetween(ctx, recorder.RecordTypeRequest, "", time.Time{}, time.Time{}); err == nil {
+		t.Fatal("expected error for empty tag")
+	}
+	if _, err := storage.FindLatestByTag(ctx, recorder.RecordType("invalid"), "env:dev", 1); err == nil {
+		t.Fatal("expected error for unknown record type")
+	}
+}
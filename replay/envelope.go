@@ -0,0 +1,277 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/stremovskyy/recorder"
+)
+
+// Tags written by this package.
+const (
+	// ReplayOfTag links a replayed response to the requestID it replays.
+	ReplayOfTag = "replay_of"
+	// PrimaryIDTag is added by RecordRequest so that ByPrimaryID can find the request.
+	PrimaryIDTag = "primary_id"
+)
+
+// Envelope is the stored form of an HTTP request. Bodies are stored so that a recorder's
+// scrubber reaches their content: JSON inline and compacted in Body, form-encoded bodies
+// as an object of values in Form (re-encoded with sorted keys on replay), other UTF-8
+// text in TextBody. Only binary bodies are stored base64-encoded in RawBody, where the
+// scrubber cannot see them.
+type Envelope struct {
+	Method    string              `json:"method"`
+	URL       string              `json:"url"`
+	Header    http.Header         `json:"header,omitempty"`
+	Body      json.RawMessage     `json:"body,omitempty"`
+	Form      map[string][]string `json:"form,omitempty"`
+	TextBody  string              `json:"text_body,omitempty"`
+	RawBody   []byte              `json:"raw_body,omitempty"`
+	PrimaryID string              `json:"primary_id,omitempty"`
+}
+
+// ResponseEnvelope is the stored form of an HTTP response, see Envelope.
+type ResponseEnvelope struct {
+	StatusCode int                 `json:"status_code"`
+	Header     http.Header         `json:"header,omitempty"`
+	Body       json.RawMessage     `json:"body,omitempty"`
+	Form       map[string][]string `json:"form,omitempty"`
+	TextBody   string              `json:"text_body,omitempty"`
+	RawBody    []byte              `json:"raw_body,omitempty"`
+}
+
+// storedBody is a body split into the envelope fields.
+type storedBody struct {
+	json json.RawMessage
+	form map[string][]string
+	text string
+	raw  []byte
+}
+
+// UnmarshalJSON also accepts header and form values that a scrubber replaced with a
+// single string.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	type plain Envelope
+	aux := struct {
+		*plain
+		Header map[string]json.RawMessage `json:"header,omitempty"`
+		Form   map[string]json.RawMessage `json:"form,omitempty"`
+	}{plain: (*plain)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	header, err := decodeValues(aux.Header)
+	if err != nil {
+		return fmt.Errorf("header %w", err)
+	}
+	form, err := decodeValues(aux.Form)
+	if err != nil {
+		return fmt.Errorf("form %w", err)
+	}
+	e.Header, e.Form = header, form
+	return nil
+}
+
+// UnmarshalJSON also accepts header and form values that a scrubber replaced with a
+// single string.
+func (e *ResponseEnvelope) UnmarshalJSON(data []byte) error {
+	type plain ResponseEnvelope
+	aux := struct {
+		*plain
+		Header map[string]json.RawMessage `json:"header,omitempty"`
+		Form   map[string]json.RawMessage `json:"form,omitempty"`
+	}{plain: (*plain)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	header, err := decodeValues(aux.Header)
+	if err != nil {
+		return fmt.Errorf("header %w", err)
+	}
+	form, err := decodeValues(aux.Form)
+	if err != nil {
+		return fmt.Errorf("form %w", err)
+	}
+	e.Header, e.Form = header, form
+	return nil
+}
+
+// NewEnvelope captures req. The body is read and replaced, so req can still be sent.
+func NewEnvelope(req *http.Request) (Envelope, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("read request body: %w", err)
+	}
+	env := Envelope{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone()}
+	b := encodeBody(req.Header.Get("Content-Type"), body)
+	env.Body, env.Form, env.TextBody, env.RawBody = b.json, b.form, b.text, b.raw
+	return env, nil
+}
+
+// NewResponseEnvelope captures resp. The body is read and replaced.
+func NewResponseEnvelope(resp *http.Response) (ResponseEnvelope, error) {
+	body, err := readBody(&resp.Body)
+	if err != nil {
+		return ResponseEnvelope{}, fmt.Errorf("read response body: %w", err)
+	}
+	env := ResponseEnvelope{StatusCode: resp.StatusCode, Header: resp.Header.Clone()}
+	b := encodeBody(resp.Header.Get("Content-Type"), body)
+	env.Body, env.Form, env.TextBody, env.RawBody = b.json, b.form, b.text, b.raw
+	return env, nil
+}
+
+// ParseEnvelope decodes a request stored by RecordRequest.
+func ParseEnvelope(data []byte) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return Envelope{}, fmt.Errorf("decode envelope: %w", err)
+	}
+	if env.Method == "" || env.URL == "" {
+		return Envelope{}, fmt.Errorf("decode envelope: method and url are required")
+	}
+	return env, nil
+}
+
+// Request rebuilds the HTTP request. Content-Length is recomputed from the body.
+func (e Envelope) Request(ctx context.Context) (*http.Request, error) {
+	body := storedBody{json: e.Body, form: e.Form, text: e.TextBody, raw: e.RawBody}.bytes()
+	req, err := http.NewRequestWithContext(ctx, e.Method, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	for name, values := range e.Header {
+		if http.CanonicalHeaderKey(name) == "Content-Length" {
+			continue
+		}
+		req.Header[name] = append([]string(nil), values...)
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+	return req, nil
+}
+
+// BodyBytes returns the response body.
+func (e ResponseEnvelope) BodyBytes() []byte {
+	return storedBody{json: e.Body, form: e.Form, text: e.TextBody, raw: e.RawBody}.bytes()
+}
+
+// RecordRequest stores req as an Envelope with rec.RecordRequest. A primaryID is also
+// stored in the envelope and in the PrimaryIDTag tag.
+func RecordRequest(ctx context.Context, rec recorder.Recorder, primaryID *string, requestID string, req *http.Request, tags map[string]string) error {
+	env, err := NewEnvelope(req)
+	if err != nil {
+		return err
+	}
+	if primaryID != nil && *primaryID != "" {
+		env.PrimaryID = *primaryID
+		tags = withTag(tags, PrimaryIDTag, *primaryID)
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("encode envelope: %w", err)
+	}
+	return rec.RecordRequest(ctx, primaryID, requestID, payload, tags)
+}
+
+// RecordResponse stores resp as a ResponseEnvelope with rec.RecordResponse.
+func RecordResponse(ctx context.Context, rec recorder.Recorder, primaryID *string, requestID string, resp *http.Response, tags map[string]string) error {
+	env, err := NewResponseEnvelope(resp)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("encode envelope: %w", err)
+	}
+	return rec.RecordResponse(ctx, primaryID, requestID, payload, tags)
+}
+
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// decodeValues decodes header or form values; a value may be a list or a single string.
+func decodeValues(raw map[string]json.RawMessage) (map[string][]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	out := make(map[string][]string, len(raw))
+	for name, value := range raw {
+		var values []string
+		if err := json.Unmarshal(value, &values); err == nil {
+			out[name] = values
+			continue
+		}
+		var single string
+		if err := json.Unmarshal(value, &single); err != nil {
+			return nil, fmt.Errorf("%q: %w", name, err)
+		}
+		out[name] = []string{single}
+	}
+	return out, nil
+}
+
+func encodeBody(contentType string, body []byte) storedBody {
+	switch {
+	case len(body) == 0:
+		return storedBody{}
+	case json.Valid(body):
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, body); err == nil {
+			return storedBody{json: compacted.Bytes()}
+		}
+	case isForm(contentType):
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return storedBody{form: values}
+		}
+	}
+	if utf8.Valid(body) {
+		return storedBody{text: string(body)}
+	}
+	return storedBody{raw: body}
+}
+
+func (b storedBody) bytes() []byte {
+	switch {
+	case len(b.json) > 0:
+		return b.json
+	case len(b.form) > 0:
+		return []byte(url.Values(b.form).Encode())
+	case b.text != "":
+		return []byte(b.text)
+	}
+	return b.raw
+}
+
+func isForm(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+func withTag(tags map[string]string, key, value string) map[string]string {
+	out := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
@@ -0,0 +1,111 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "https://api.example.com/v1/charges?mode=test", strings.NewReader("{\n  \"amount\": 100\n}"))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", "20")
+	req.Header.Set("Host", "api.example.com")
+
+	env, err := NewEnvelope(req)
+	if err != nil {
+		t.Fatalf("NewEnvelope returned error: %v", err)
+	}
+	if string(env.Body) != `{"amount":100}` || env.RawBody != nil {
+		t.Fatalf("expected an inline JSON body, got %q / %q", env.Body, env.RawBody)
+	}
+	if body, _ := io.ReadAll(req.Body); len(body) == 0 {
+		t.Fatal("expected the request body to be restored")
+	}
+
+	data, _ := json.Marshal(env)
+	parsed, err := ParseEnvelope(data)
+	if err != nil {
+		t.Fatalf("ParseEnvelope returned error: %v", err)
+	}
+	rebuilt, err := parsed.Request(context.Background())
+	if err != nil {
+		t.Fatalf("Request returned error: %v", err)
+	}
+	body, _ := io.ReadAll(rebuilt.Body)
+	if rebuilt.Method != http.MethodPost || rebuilt.URL.String() != "https://api.example.com/v1/charges?mode=test" || string(body) != `{"amount":100}` {
+		t.Fatalf("unexpected request %s %s %s", rebuilt.Method, rebuilt.URL, body)
+	}
+	if rebuilt.ContentLength != int64(len(body)) || rebuilt.Header.Get("Content-Length") != "" {
+		t.Fatalf("expected content length to be recomputed, got %d %v", rebuilt.ContentLength, rebuilt.Header)
+	}
+	if rebuilt.Host != "api.example.com" || rebuilt.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected headers %v (host %q)", rebuilt.Header, rebuilt.Host)
+	}
+}
+
+func TestEnvelopeRawBodies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/upload", strings.NewReader("a=1&b=2"))
+	env, err := NewEnvelope(req)
+	if err != nil {
+		t.Fatalf("NewEnvelope returned error: %v", err)
+	}
+	if env.Body != nil || env.RawBody != nil || env.TextBody != "a=1&b=2" {
+		t.Fatalf("expected a text body, got %+v", env)
+	}
+
+	binary := []byte{0xff, 0xfe, 0x00, 0x01}
+	env, err = NewEnvelope(httptest.NewRequest(http.MethodPut, "/upload", bytes.NewReader(binary)))
+	if err != nil {
+		t.Fatalf("NewEnvelope returned error: %v", err)
+	}
+	if env.TextBody != "" || !bytes.Equal(env.RawBody, binary) {
+		t.Fatalf("expected a raw body, got %+v", env)
+	}
+
+	resp := &http.Response{StatusCode: http.StatusAccepted, Header: http.Header{"X-Id": {"1"}}, Body: io.NopCloser(strings.NewReader("ok"))}
+	respEnv, err := NewResponseEnvelope(resp)
+	if err != nil {
+		t.Fatalf("NewResponseEnvelope returned error: %v", err)
+	}
+	if respEnv.StatusCode != http.StatusAccepted || string(respEnv.BodyBytes()) != "ok" {
+		t.Fatalf("unexpected response envelope %+v", respEnv)
+	}
+
+	if _, err := ParseEnvelope([]byte(`{"url":"/x"}`)); err == nil {
+		t.Fatal("expected error for an envelope without a method")
+	}
+}
+
+func TestEnvelopeFormBodies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "https://api.example.com/login", strings.NewReader("user=ann&password=hunter2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	env, err := NewEnvelope(req)
+	if err != nil {
+		t.Fatalf("NewEnvelope returned error: %v", err)
+	}
+	if env.Form["password"][0] != "hunter2" || env.TextBody != "" || env.RawBody != nil {
+		t.Fatalf("expected a form body, got %+v", env)
+	}
+
+	// A scrubber replaces a matched value list with a single string.
+	data := []byte(`{"method":"POST","url":"/login","form":{"user":["ann"],"password":"[REDACTED]"}}`)
+	parsed, err := ParseEnvelope(data)
+	if err != nil {
+		t.Fatalf("ParseEnvelope returned error: %v", err)
+	}
+	rebuilt, err := parsed.Request(context.Background())
+	if err != nil {
+		t.Fatalf("Request returned error: %v", err)
+	}
+	body, _ := io.ReadAll(rebuilt.Body)
+	if string(body) != "password=%5BREDACTED%5D&user=ann" {
+		t.Fatalf("unexpected form body %q", body)
+	}
+}
@@ -0,0 +1,363 @@
+// Package replay re-sends recorded HTTP requests, e.g. against a provider's sandbox, and
+// records the new responses next to the originals.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stremovskyy/recorder"
+)
+
+// Selector lists the requestIDs of the recorded requests to replay.
+type Selector func(ctx context.Context, rec recorder.Recorder, resolve KeyResolver) ([]string, error)
+
+// KeyResolver turns a key returned by Recorder.FindByTag into the requestID of a request
+// record. It reports false for keys of other record types.
+type KeyResolver func(key string) (string, bool)
+
+// RequestEditor changes a request before it is sent, e.g. to add sandbox credentials in
+// place of the scrubbed originals.
+type RequestEditor func(req *http.Request) error
+
+// Result describes the replay of one request. RequestID is the requestID the request was
+// recorded with, without the primary ID that Redis keys prepend. ReplayID is empty when
+// the request could not be loaded. Err is set when the request could not be loaded, sent
+// or recorded.
+type Result struct {
+	RequestID  string
+	ReplayID   string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+type Option func(*Replayer)
+
+// Replayer loads request records and sends them again.
+type Replayer struct {
+	rec      recorder.Recorder
+	client   *http.Client
+	baseURL  string
+	editors  []RequestEditor
+	resolve  KeyResolver
+	replayID func(requestID string) string
+	interval time.Duration
+	tags     map[string]string
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// New returns a Replayer that loads requests from rec and records the responses to it.
+func New(rec recorder.Recorder, opts ...Option) (*Replayer, error) {
+	if rec == nil {
+		return nil, fmt.Errorf("replay: recorder must not be nil")
+	}
+	r := &Replayer{
+		rec:      rec,
+		client:   http.DefaultClient,
+		resolve:  DefaultKeyResolver,
+		replayID: defaultReplayID,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(r)
+		}
+	}
+	if r.baseURL != "" {
+		u, err := url.Parse(r.baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("replay: parse base url: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("replay: base url %q must be absolute", r.baseURL)
+		}
+	}
+	return r, nil
+}
+
+// WithHTTPClient sets the client used to send requests. http.DefaultClient is used by
+// default.
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *Replayer) {
+		if client != nil {
+			r.client = client
+		}
+	}
+}
+
+// WithBaseURL sends every request to baseURL, e.g. "https://sandbox.example.com/v2",
+// keeping the recorded path and query. The base path is prepended to the recorded one.
+func WithBaseURL(baseURL string) Option {
+	return func(r *Replayer) {
+		r.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithRequestEditor adds editors run in order on every request before it is sent.
+func WithRequestEditor(editors ...RequestEditor) Option {
+	return func(r *Replayer) {
+		for _, e := range editors {
+			if e != nil {
+				r.editors = append(r.editors, e)
+			}
+		}
+	}
+}
+
+// WithRateLimit sends at most n requests per period. Requests are spread evenly.
+func WithRateLimit(n int, per time.Duration) Option {
+	return func(r *Replayer) {
+		if n > 0 && per > 0 {
+			r.interval = per / time.Duration(n)
+		}
+	}
+}
+
+// WithKeyResolver replaces DefaultKeyResolver for selectors based on tags.
+func WithKeyResolver(resolve KeyResolver) Option {
+	return func(r *Replayer) {
+		if resolve != nil {
+			r.resolve = resolve
+		}
+	}
+}
+
+// WithReplayID sets how the requestID of a replayed response is derived from the
+// original one. By default it is "<requestID>.replay.<unix nanoseconds>".
+func WithReplayID(fn func(requestID string) string) Option {
+	return func(r *Replayer) {
+		if fn != nil {
+			r.replayID = fn
+		}
+	}
+}
+
+// WithTags adds tags to every recorded response.
+func WithTags(tags map[string]string) Option {
+	return func(r *Replayer) {
+		if r.tags == nil {
+			r.tags = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			r.tags[k] = v
+		}
+	}
+}
+
+// ByRequestID selects the given requests.
+func ByRequestID(requestIDs ...string) Selector {
+	return func(context.Context, recorder.Recorder, KeyResolver) ([]string, error) {
+		return append([]string(nil), requestIDs...), nil
+	}
+}
+
+// ByTag selects the requests found by Recorder.FindByTag(tag), such as "endpoint:charge".
+func ByTag(tag string) Selector {
+	return func(ctx context.Context, rec recorder.Recorder, resolve KeyResolver) ([]string, error) {
+		keys, err := rec.FindByTag(ctx, tag)
+		if err != nil {
+			return nil, fmt.Errorf("find by tag %q: %w", tag, err)
+		}
+		seen := make(map[string]struct{}, len(keys))
+		ids := make([]string, 0, len(keys))
+		for _, key := range keys {
+			id, ok := resolve(key)
+			if !ok {
+				continue
+			}
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+}
+
+// ByPrimaryID selects the requests recorded by RecordRequest with primaryID.
+func ByPrimaryID(primaryID string) Selector {
+	return ByTag(PrimaryIDTag + ":" + primaryID)
+}
+
+// DefaultKeyResolver understands the keys returned by the storages of this module:
+// "<prefix>:request:<id>" (Redis), "request:<id>" (GORM) and bare requestIDs. Keys of
+// responses, errors and other records are skipped. For records saved with a primary ID,
+// Redis keys yield "<primaryID>:<requestID>", the ID its Load expects; Replay strips the
+// primary ID again before linking the replay to the original.
+func DefaultKeyResolver(key string) (string, bool) {
+	parts := strings.Split(key, ":")
+	for i, part := range parts {
+		switch recorder.RecordType(part) {
+		case recorder.RecordTypeRequest:
+			id := strings.Join(parts[i+1:], ":")
+			return id, id != ""
+		case recorder.RecordTypeResponse, recorder.RecordTypeError, recorder.RecordTypeMetrics, recorder.RecordTypeScrubReport:
+			return "", false
+		}
+	}
+	return key, key != ""
+}
+
+// Replay sends the selected requests one after another and records each response under
+// a new requestID tagged with ReplayOfTag. Failures of single requests are reported in
+// their Result, including a transport error, which is also recorded with RecordError.
+// The returned error is set when the selection fails or ctx is done.
+func (r *Replayer) Replay(ctx context.Context, selector Selector) ([]Result, error) {
+	ids, err := selector(ctx, r.rec, r.resolve)
+	if err != nil {
+		return nil, fmt.Errorf("replay: select requests: %w", err)
+	}
+	results := make([]Result, 0, len(ids))
+	for _, id := range ids {
+		if err := r.wait(ctx); err != nil {
+			return results, err
+		}
+		results = append(results, r.replayOne(ctx, id))
+	}
+	return results, nil
+}
+
+func (r *Replayer) replayOne(ctx context.Context, requestID string) Result {
+	result := Result{RequestID: requestID}
+	data, err := r.rec.GetRequest(ctx, requestID)
+	if err != nil {
+		result.Err = fmt.Errorf("load request: %w", err)
+		return result
+	}
+	env, err := ParseEnvelope(data)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if env.PrimaryID != "" {
+		result.RequestID = strings.TrimPrefix(requestID, env.PrimaryID+":")
+	}
+	result.ReplayID = r.replayID(result.RequestID)
+
+	req, err := r.buildRequest(ctx, env)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	var primaryID *string
+	if env.PrimaryID != "" {
+		primaryID = &env.PrimaryID
+	}
+	tags := withTag(r.tags, ReplayOfTag, result.RequestID)
+
+	start := time.Now()
+	resp, err := r.client.Do(req)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Err = fmt.Errorf("send request: %w", err)
+		if recErr := r.rec.RecordError(ctx, primaryID, result.ReplayID, err, tags); recErr != nil {
+			result.Err = errors.Join(result.Err, fmt.Errorf("record error: %w", recErr))
+		}
+		return result
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+
+	respEnv, err := NewResponseEnvelope(resp)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	payload, err := json.Marshal(respEnv)
+	if err != nil {
+		result.Err = fmt.Errorf("encode envelope: %w", err)
+		return result
+	}
+	if err := r.rec.RecordResponse(ctx, primaryID, result.ReplayID, payload, tags); err != nil {
+		result.Err = fmt.Errorf("record response: %w", err)
+	}
+	return result
+}
+
+func (r *Replayer) buildRequest(ctx context.Context, env Envelope) (*http.Request, error) {
+	if r.baseURL != "" {
+		target, err := rebase(r.baseURL, env.URL)
+		if err != nil {
+			return nil, err
+		}
+		env.URL = target
+		if env.Header.Get("Host") != "" {
+			env.Header = env.Header.Clone()
+			env.Header.Del("Host")
+		}
+	}
+	req, err := env.Request(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, edit := range r.editors {
+		if err := edit(req); err != nil {
+			return nil, fmt.Errorf("edit request: %w", err)
+		}
+	}
+	return req, nil
+}
+
+// rebase keeps the path, query and fragment of recorded and takes the rest from base.
+func rebase(base, recorded string) (string, error) {
+	u, err := url.Parse(recorded)
+	if err != nil {
+		return "", fmt.Errorf("parse recorded url: %w", err)
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("parse base url: %w", err)
+	}
+	if u.Path != "" {
+		b = b.JoinPath(u.Path)
+	}
+	b.RawQuery, b.Fragment, b.RawFragment = u.RawQuery, u.Fragment, u.RawFragment
+	return b.String(), nil
+}
+
+// wait blocks until the rate limit allows the next request.
+func (r *Replayer) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if r.interval <= 0 {
+		return nil
+	}
+	r.mu.Lock()
+	now := time.Now()
+	slot := r.next
+	if slot.Before(now) {
+		slot = now
+	}
+	r.next = slot.Add(r.interval)
+	r.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func defaultReplayID(requestID string) string {
+	return requestID + ".replay." + strconv.FormatInt(time.Now().UnixNano(), 10)
+}
@@ -0,0 +1,305 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+
+	"github.com/stremovskyy/recorder"
+	"github.com/stremovskyy/recorder/redis_recorder"
+)
+
+type memoryStorage struct {
+	mu      sync.Mutex
+	records map[recorder.RecordType]map[string]recorder.Record
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{records: make(map[recorder.RecordType]map[string]recorder.Record)}
+}
+
+func (s *memoryStorage) Save(_ context.Context, record recorder.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records[record.Type] == nil {
+		s.records[record.Type] = make(map[string]recorder.Record)
+	}
+	s.records[record.Type][record.RequestID] = record
+	return nil
+}
+
+func (s *memoryStorage) Load(_ context.Context, recordType recorder.RecordType, requestID string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[recordType][requestID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return record.Payload, nil
+}
+
+// FindByTag returns "<type>:<requestID>" keys like the GORM storage.
+func (s *memoryStorage) FindByTag(_ context.Context, tag string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, value, _ := strings.Cut(tag, ":")
+	var keys []string
+	for recordType, records := range s.records {
+		for id, record := range records {
+			if v, ok := record.Tags[key]; ok && v == value {
+				keys = append(keys, string(recordType)+":"+id)
+			}
+		}
+	}
+	return keys, nil
+}
+
+func TestReplaySendsRecordedRequestsToSandbox(t *testing.T) {
+	ctx := context.Background()
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r.Method+" "+r.URL.String()+" "+r.Header.Get("Authorization")+" "+string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"ch_1","status":"ok"}`))
+	}))
+	defer server.Close()
+
+	storage := newMemoryStorage()
+	rec := recorder.New(storage, recorder.WithScrubber(recorder.NewScrubber()))
+
+	primaryID := "order-7"
+	original := httptest.NewRequest(http.MethodPost, "https://api.provider.com/v1/charges?mode=live", strings.NewReader(`{"amount":100,"card":"4111"}`))
+	original.Header.Set("Authorization", "Bearer live-secret")
+	if err := RecordRequest(ctx, rec, &primaryID, "req-1", original, map[string]string{"endpoint": "charge"}); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if strings.Contains(string(storage.records[recorder.RecordTypeRequest]["req-1"].Payload), "live-secret") {
+		t.Fatal("expected the stored envelope to be scrubbed")
+	}
+
+	replayer, err := New(rec,
+		WithHTTPClient(server.Client()),
+		WithBaseURL(server.URL+"/sandbox"),
+		WithRequestEditor(func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer sandbox")
+			return nil
+		}),
+		WithReplayID(func(id string) string { return id + ".replay" }),
+		WithTags(map[string]string{"env": "sandbox"}),
+	)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	results, err := replayer.Replay(ctx, ByPrimaryID(primaryID))
+	if err != nil {
+		t.Fatalf("Replay returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil || results[0].StatusCode != http.StatusCreated || results[0].ReplayID != "req-1.replay" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	want := `POST /sandbox/v1/charges?mode=live Bearer sandbox {"amount":100,"card":"4111"}`
+	if len(received) != 1 || received[0] != want {
+		t.Fatalf("unexpected requests %q", received)
+	}
+
+	saved := storage.records[recorder.RecordTypeResponse]["req-1.replay"]
+	if saved.Tags[ReplayOfTag] != "req-1" || saved.Tags["env"] != "sandbox" || saved.PrimaryID == nil || *saved.PrimaryID != primaryID {
+		t.Fatalf("unexpected response record %+v", saved)
+	}
+	var resp ResponseEnvelope
+	if err := json.Unmarshal(saved.Payload, &resp); err != nil {
+		t.Fatalf("invalid response envelope: %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated || string(resp.BodyBytes()) != `{"id":"ch_1","status":"ok"}` {
+		t.Fatalf("unexpected response envelope %+v", resp)
+	}
+
+	linked, err := replayer.Replay(ctx, ByTag(ReplayOfTag+":req-1"))
+	if err != nil || len(linked) != 0 {
+		t.Fatalf("expected response keys to be skipped, got %+v (%v)", linked, err)
+	}
+}
+
+func TestReplayReportsFailuresPerRequest(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	url := server.URL
+	server.Close() // connections are refused from now on
+
+	storage := newMemoryStorage()
+	rec := recorder.New(storage)
+	if err := RecordRequest(ctx, rec, nil, "req-1", httptest.NewRequest(http.MethodGet, url+"/status", nil), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	replayer, err := New(rec, WithReplayID(func(id string) string { return "replayed-" + id }))
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	results, err := replayer.Replay(ctx, ByRequestID("req-1", "missing"))
+	if err != nil {
+		t.Fatalf("Replay returned error: %v", err)
+	}
+	if len(results) != 2 || results[0].Err == nil || results[1].Err == nil {
+		t.Fatalf("expected both replays to fail, got %+v", results)
+	}
+	saved, ok := storage.records[recorder.RecordTypeError]["replayed-req-1"]
+	if !ok || saved.Tags[ReplayOfTag] != "req-1" || saved.Tags[recorder.ErrorClassTag] != recorder.ErrorClassNetwork {
+		t.Fatalf("expected the transport error to be recorded, got %+v", saved)
+	}
+}
+
+func TestReplayRateLimit(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	rec := recorder.New(newMemoryStorage())
+	ids := []string{"a", "b", "c"}
+	for _, id := range ids {
+		if err := RecordRequest(ctx, rec, nil, id, httptest.NewRequest(http.MethodGet, server.URL, nil), nil); err != nil {
+			t.Fatalf("RecordRequest returned error: %v", err)
+		}
+	}
+
+	replayer, _ := New(rec, WithHTTPClient(server.Client()), WithRateLimit(20, time.Second))
+	if _, err := replayer.Replay(ctx, ByRequestID(ids...)); err != nil {
+		t.Fatalf("Replay returned error: %v", err)
+	}
+	if len(times) != 3 || times[2].Sub(times[0]) < 90*time.Millisecond {
+		t.Fatalf("expected requests to be spaced by 50ms, got %v", times)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := replayer.Replay(canceled, ByRequestID(ids...)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDefaultKeyResolverAndOptions(t *testing.T) {
+	cases := map[string]string{
+		"recorder:request:order-7:req-1": "order-7:req-1",
+		"request:req-2":                  "req-2",
+		"req-3":                          "req-3",
+	}
+	for key, want := range cases {
+		if got, ok := DefaultKeyResolver(key); !ok || got != want {
+			t.Errorf("DefaultKeyResolver(%q) = %q, %v", key, got, ok)
+		}
+	}
+	for _, key := range []string{"recorder:response:req-1", "error:req-2", "request:"} {
+		if _, ok := DefaultKeyResolver(key); ok {
+			t.Errorf("expected %q to be skipped", key)
+		}
+	}
+
+	if _, err := New(nil); err == nil {
+		t.Error("expected error for a nil recorder")
+	}
+	if _, err := New(recorder.New(newMemoryStorage()), WithBaseURL("/relative")); err == nil {
+		t.Error("expected error for a relative base url")
+	}
+}
+
+func TestRecordRequestScrubsFormAndTextBodies(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	rec := recorder.New(storage, recorder.WithScrubber(recorder.NewScrubber(recorder.WithDetectors())))
+
+	form := httptest.NewRequest(http.MethodPost, "https://api.example.com/pay", strings.NewReader("card=4111111111111111&password=hunter2"))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := RecordRequest(ctx, rec, nil, "form", form, nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	text := httptest.NewRequest(http.MethodPost, "https://api.example.com/note", strings.NewReader("pay with 4111 1111 1111 1111 please"))
+	text.Header.Set("Content-Type", "text/plain")
+	if err := RecordRequest(ctx, rec, nil, "text", text, nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	for _, id := range []string{"form", "text"} {
+		stored := string(storage.records[recorder.RecordTypeRequest][id].Payload)
+		for _, secret := range []string{"hunter2", "4111111111111111", "4111 1111 1111 1111"} {
+			if strings.Contains(stored, secret) {
+				t.Fatalf("expected %q to be redacted at rest, got %s", secret, stored)
+			}
+		}
+	}
+
+	data, err := rec.GetRequest(ctx, "form")
+	if err != nil {
+		t.Fatalf("GetRequest returned error: %v", err)
+	}
+	env, err := ParseEnvelope(data)
+	if err != nil {
+		t.Fatalf("ParseEnvelope returned error: %v", err)
+	}
+	if got := env.Form["password"]; len(got) != 1 || got[0] != "[REDACTED]" {
+		t.Fatalf("expected a redacted password, got %v", env.Form)
+	}
+}
+
+func TestReplayRedisRecordsWithPrimaryID(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	mr := miniredis.RunT(t)
+	rec, err := redis_recorder.NewRedisRecorderWithValidation(&redis_recorder.Options{Addr: mr.Addr(), Prefix: "rr"})
+	if err != nil {
+		t.Fatalf("NewRedisRecorderWithValidation returned error: %v", err)
+	}
+	defer rec.Close()
+
+	primaryID := "order-1"
+	if err := RecordRequest(ctx, rec, &primaryID, "req-1", httptest.NewRequest(http.MethodPost, server.URL+"/charge", strings.NewReader(`{"amount":1}`)), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+
+	replayer, err := New(rec, WithHTTPClient(server.Client()), WithReplayID(func(id string) string { return id + ".replay" }))
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	results, err := replayer.Replay(ctx, ByPrimaryID(primaryID))
+	if err != nil {
+		t.Fatalf("Replay returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil || results[0].RequestID != "req-1" || results[0].ReplayID != "req-1.replay" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	keys, err := rec.FindByTag(ctx, ReplayOfTag+":req-1")
+	if err != nil {
+		t.Fatalf("FindByTag returned error: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "rr:response:order-1:req-1.replay" {
+		t.Fatalf("unexpected replay keys %v", keys)
+	}
+	if _, err := rec.GetResponse(ctx, primaryID+":req-1.replay"); err != nil {
+		t.Fatalf("GetResponse returned error: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package recorder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SnapshotSink receives the snapshots pushed by a Reporter.
+type SnapshotSink func(ctx context.Context, snapshot MetricsSnapshot) error
+
+type ReporterOption func(*Reporter)
+
+// Reporter periodically pushes Metrics snapshots to a SnapshotSink.
+type Reporter struct {
+	metrics  SnapshotMetrics
+	interval time.Duration
+	sink     SnapshotSink
+	reset    bool
+	onError  func(error)
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// pending holds reset snapshots the sink rejected, oldest first.
+	pendingMu sync.Mutex
+	pending   []MetricsSnapshot
+}
+
+// maxPendingSnapshots bounds how many rejected snapshots a resetting Reporter keeps.
+const maxPendingSnapshots = 16
+
+// NewReporter builds a Reporter that pushes a snapshot of metrics to sink every interval.
+// metrics must implement SnapshotMetrics, as the Metrics of this module do.
+func NewReporter(metrics Metrics, interval time.Duration, sink SnapshotSink, opts ...ReporterOption) (*Reporter, error) {
+	if metrics == nil {
+		return nil, fmt.Errorf("reporter: metrics must not be nil")
+	}
+	snapshots, ok := metrics.(SnapshotMetrics)
+	if !ok {
+		return nil, fmt.Errorf("reporter: %T does not implement SnapshotMetrics", metrics)
+	}
+	if sink == nil {
+		return nil, fmt.Errorf("reporter: sink must not be nil")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("reporter: interval must be positive")
+	}
+	r := &Reporter{
+		metrics:  snapshots,
+		interval: interval,
+		sink:     sink,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(r)
+		}
+	}
+	return r, nil
+}
+
+// WithReporterReset takes each snapshot with SnapshotMetrics.SnapshotAndReset, so every
+// snapshot holds only the counts and observations recorded since the previous one, and
+// the current gauges. Snapshots the sink rejects are kept, up to 16, and pushed again
+// before the next one.
+func WithReporterReset() ReporterOption {
+	return func(r *Reporter) {
+		r.reset = true
+	}
+}
+
+// WithReporterErrorHandler is called with every error returned by the sink.
+func WithReporterErrorHandler(fn func(error)) ReporterOption {
+	return func(r *Reporter) {
+		r.onError = fn
+	}
+}
+
+// Report pushes a single snapshot immediately, preceded by any snapshots still pending
+// from failed pushes.
+func (r *Reporter) Report(ctx context.Context) error {
+	if !r.reset {
+		return r.push(ctx, r.metrics.Snapshot())
+	}
+
+	r.pendingMu.Lock()
+	defer r.pendingMu.Unlock()
+
+	r.pending = append(r.pending, r.metrics.SnapshotAndReset())
+	if extra := len(r.pending) - maxPendingSnapshots; extra > 0 {
+		r.pending = r.pending[extra:]
+	}
+	for len(r.pending) > 0 {
+		if err := r.push(ctx, r.pending[0]); err != nil {
+			return err
+		}
+		r.pending = r.pending[1:]
+	}
+	r.pending = nil
+	return nil
+}
+
+func (r *Reporter) push(ctx context.Context, snapshot MetricsSnapshot) error {
+	if err := r.sink(ctx, snapshot); err != nil {
+		if r.onError != nil {
+			r.onError(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Start runs the reporter in the background until Stop is called or ctx is done.
+// Calling Start on a running reporter is a no-op.
+func (r *Reporter) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func(done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = r.Report(ctx)
+			}
+		}
+	}(r.done)
+}
+
+// Stop halts the background loop and pushes a final snapshot so nothing recorded since
+// the last tick is lost.
+func (r *Reporter) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel, r.done = nil, nil
+	r.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+	return r.Report(ctx)
+}
@@ -0,0 +1,177 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReporterPushesSnapshots(t *testing.T) {
+	m := NewMetrics()
+	m.IncrementCounter("requests", nil)
+
+	var mu sync.Mutex
+	var pushed []MetricsSnapshot
+	pushedOnce := make(chan struct{}, 1)
+	reporter, err := NewReporter(m, 5*time.Millisecond, func(_ context.Context, s MetricsSnapshot) error {
+		mu.Lock()
+		pushed = append(pushed, s)
+		mu.Unlock()
+		select {
+		case pushedOnce <- struct{}{}:
+		default:
+		}
+		return nil
+	}, WithReporterReset())
+	if err != nil {
+		t.Fatalf("NewReporter returned error: %v", err)
+	}
+
+	reporter.Start(context.Background())
+	select {
+	case <-pushedOnce:
+	case <-time.After(time.Second):
+		t.Fatal("expected a snapshot to be pushed")
+	}
+
+	m.IncrementCounter("late", nil)
+	if err := reporter.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pushed[0].Counters) != 1 || pushed[0].Counters[0].Name != "requests" {
+		t.Fatalf("unexpected first snapshot: %+v", pushed[0])
+	}
+	last := pushed[len(pushed)-1]
+	if len(last.Counters) != 1 || last.Counters[0].Name != "late" {
+		t.Fatalf("expected final flush with values since last reset, got %+v", last)
+	}
+}
+
+func TestReporterReportsSinkErrors(t *testing.T) {
+	sinkErr := errors.New("sink down")
+	var handled error
+	var pushed []MetricsSnapshot
+	failing := true
+	m := NewMetrics()
+	m.IncrementCounter("requests", nil)
+
+	reporter, err := NewReporter(m, time.Second, func(_ context.Context, s MetricsSnapshot) error {
+		if failing {
+			return sinkErr
+		}
+		pushed = append(pushed, s)
+		return nil
+	}, WithReporterReset(), WithReporterErrorHandler(func(err error) { handled = err }))
+	if err != nil {
+		t.Fatalf("NewReporter returned error: %v", err)
+	}
+
+	if err := reporter.Report(context.Background()); !errors.Is(err, sinkErr) {
+		t.Fatalf("expected sink error, got %v", err)
+	}
+	if !errors.Is(handled, sinkErr) {
+		t.Fatalf("expected error handler to be called, got %v", handled)
+	}
+
+	m.IncrementCounter("late", nil)
+	failing = false
+	if err := reporter.Report(context.Background()); err != nil {
+		t.Fatalf("Report returned error: %v", err)
+	}
+	if len(pushed) != 2 {
+		t.Fatalf("expected the rejected snapshot to be pushed again, got %+v", pushed)
+	}
+	if len(pushed[0].Counters) != 1 || pushed[0].Counters[0].Name != "requests" {
+		t.Fatalf("expected metrics to be kept when the push fails, got %+v", pushed[0])
+	}
+	if len(pushed[1].Counters) != 1 || pushed[1].Counters[0].Name != "late" {
+		t.Fatalf("unexpected second snapshot: %+v", pushed[1])
+	}
+}
+
+func TestReporterResetKeepsConcurrentValues(t *testing.T) {
+	m := NewMetrics()
+	var total int64
+	var mu sync.Mutex
+	reporter, err := NewReporter(m, time.Second, func(_ context.Context, s MetricsSnapshot) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range s.Counters {
+			total += c.Value
+		}
+		return nil
+	}, WithReporterReset())
+	if err != nil {
+		t.Fatalf("NewReporter returned error: %v", err)
+	}
+
+	const writes = 2000
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < writes; i++ {
+			m.IncrementCounter("requests", nil)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		_ = reporter.Report(context.Background())
+	}
+	wg.Wait()
+	if err := reporter.Report(context.Background()); err != nil {
+		t.Fatalf("Report returned error: %v", err)
+	}
+
+	if total != writes {
+		t.Fatalf("expected %d counted requests across snapshots, got %d", writes, total)
+	}
+}
+
+func TestNewReporterValidation(t *testing.T) {
+	sink := func(context.Context, MetricsSnapshot) error { return nil }
+	if _, err := NewReporter(nil, time.Second, sink); err == nil {
+		t.Fatal("expected error for nil metrics")
+	}
+	if _, err := NewReporter(NewMetrics(), time.Second, nil); err == nil {
+		t.Fatal("expected error for nil sink")
+	}
+	if _, err := NewReporter(NewMetrics(), 0, sink); err == nil {
+		t.Fatal("expected error for non-positive interval")
+	}
+	if _, err := NewReporter(struct{ Metrics }{NewMetrics()}, time.Second, sink); err == nil {
+		t.Fatal("expected error for metrics without snapshots")
+	}
+}
+
+func TestReporterResetKeepsGauges(t *testing.T) {
+	for name, m := range map[string]SnapshotMetrics{"memory": NewMetrics(), "prometheus": NewPrometheusMetrics()} {
+		m.SetGauge("pool.idle", 3, nil)
+		m.IncrementCounter("requests", nil)
+
+		var pushed []MetricsSnapshot
+		reporter, err := NewReporter(m, time.Second, func(_ context.Context, s MetricsSnapshot) error {
+			pushed = append(pushed, s)
+			return nil
+		}, WithReporterReset())
+		if err != nil {
+			t.Fatalf("%s: NewReporter returned error: %v", name, err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := reporter.Report(context.Background()); err != nil {
+				t.Fatalf("%s: Report returned error: %v", name, err)
+			}
+		}
+
+		if len(pushed[1].Counters) != 0 {
+			t.Fatalf("%s: expected counters to be reset, got %+v", name, pushed[1].Counters)
+		}
+		if len(pushed[1].Gauges) != 1 || pushed[1].Gauges[0].Value != 3 {
+			t.Fatalf("%s: expected the gauge to survive the reset, got %+v", name, pushed[1].Gauges)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// Content types understood by Scrubber.ScrubContent.
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "application/xml"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+)
+
+// ErrUnsupportedContent is returned by ScrubContent when the payload format is unknown.
+var ErrUnsupportedContent = errors.New("scrubber: unsupported content type")
+
+// ScrubContent scrubs data according to contentType. JSON, XML (including +json/+xml
+// suffixes such as application/soap+xml), form-encoded and ContentTypeErrorRecord
+// payloads are supported. An empty contentType sniffs the format from the payload. Any
+// other payload is treated as plain text when the scrubber has detectors (see
+// WithDetectors).
+func (s *Scrubber) ScrubContent(contentType string, data []byte) ([]byte, error) {
+	return s.scrubContent(contentType, data, false)
+}
+
+func (s *Scrubber) scrubContent(contentType string, data []byte, orderedJSON bool) ([]byte, error) {
+	format := contentType
+	if format == "" {
+		format = SniffContentType(data)
+	}
+	switch normalizeContentType(format) {
+	case ContentTypeJSON:
+		if orderedJSON {
+			return s.ScrubJSONOrdered(data)
+		}
+		return s.ScrubJSON(data)
+	case ContentTypeXML:
+		return s.ScrubXML(data)
+	case ContentTypeForm:
+		return s.ScrubForm(data)
+	case ContentTypeErrorRecord:
+		return s.ScrubErrorRecord(data)
+	default:
+		if s != nil && len(s.currentDetectors()) > 0 {
+			return s.ScrubText(data), nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContent, format)
+	}
+}
+
+// SniffContentType guesses whether data is JSON, XML or form-encoded. It returns an empty
+// string when none of them fits.
+func SniffContentType(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0:
+		return ""
+	case json.Valid(trimmed):
+		return ContentTypeJSON
+	case trimmed[0] == '<':
+		return ContentTypeXML
+	}
+	if bytes.ContainsRune(trimmed, '=') && !bytes.ContainsAny(trimmed, " \t\r\n") {
+		if _, err := url.ParseQuery(string(trimmed)); err == nil {
+			return ContentTypeForm
+		}
+	}
+	return ""
+}
+
+func normalizeContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	switch {
+	case mediaType == ContentTypeForm, mediaType == ContentTypeErrorRecord:
+		return mediaType
+	case mediaType == ContentTypeJSON, strings.HasSuffix(mediaType, "+json"), mediaType == "text/json":
+		return ContentTypeJSON
+	case mediaType == ContentTypeXML, mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return ContentTypeXML
+	default:
+		return mediaType
+	}
+}
+
+// ScrubForm scrubs an application/x-www-form-urlencoded payload. Keys are matched like
+// JSON object keys and repeated values get "[i]" path elements. The output is re-encoded
+// with sorted keys.
+func (s *Scrubber) ScrubForm(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	if s == nil || len(trimmed) == 0 {
+		return append([]byte(nil), data...), nil
+	}
+	values, err := url.ParseQuery(string(trimmed))
+	if err != nil {
+		return nil, fmt.Errorf("scrubber: decode form: %w", err)
+	}
+	scrubbed, ok := s.scrubValue(map[string][]string(values), nil).(map[string][]string)
+	if !ok {
+		return nil, fmt.Errorf("scrubber: unexpected form value")
+	}
+	return []byte(url.Values(scrubbed).Encode()), nil
+}
+
+// ScrubXML scrubs an XML document. Elements are matched by their local name with the
+// path of their ancestors, e.g. Envelope.Body.Login.Password; attributes are matched by
+// name with an "@name" path element. The value of an element is its text when it has no
+// child elements. A matching element has its whole content replaced; namespace prefixes,
+// comments and processing instructions are kept.
+func (s *Scrubber) ScrubXML(data []byte) ([]byte, error) {
+	if s == nil || len(bytes.TrimSpace(data)) == 0 {
+		return append([]byte(nil), data...), nil
+	}
+	root, err := parseXMLTree(data)
+	if err != nil {
+		return nil, fmt.Errorf("scrubber: decode xml: %w", err)
+	}
+	s.scrubXMLContent(root, nil)
+
+	var buf bytes.Buffer
+	writeXMLContent(&buf, root)
+	return buf.Bytes(), nil
+}
+
+type xmlNode struct {
+	start   xml.StartElement
+	content []any // xml.CharData, xml.Comment, xml.ProcInst, xml.Directive or *xmlNode
+}
+
+func parseXMLTree(data []byte) ([]any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var root []any
+	var stack []*xmlNode
+	appendContent := func(item any) {
+		if len(stack) == 0 {
+			root = append(root, item)
+			return
+		}
+		top := stack[len(stack)-1]
+		top.content = append(top.content, item)
+	}
+
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{start: t.Copy()}
+			appendContent(node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].start.Name != t.Name {
+				return nil, fmt.Errorf("unexpected end element </%s>", qualifiedName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			appendContent(xml.CopyToken(token))
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unclosed element <%s>", qualifiedName(stack[len(stack)-1].start.Name))
+	}
+	return root, nil
+}
+
+func (s *Scrubber) scrubXMLContent(content []any, path []string) {
+	for _, item := range content {
+		if node, ok := item.(*xmlNode); ok {
+			s.scrubXMLNode(node, appendPath(path, node.start.Name.Local))
+		}
+	}
+}
+
+func (s *Scrubber) scrubXMLNode(node *xmlNode, path []string) {
+	for i, attr := range node.start.Attr {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+		ctx := FieldContext{Path: appendPath(path, "@"+attr.Name.Local), Key: attr.Name.Local, Value: attr.Value}
+		if replaced, ok := s.applyRules(ctx); ok {
+			node.start.Attr[i].Value = toString(replaced)
+		}
+	}
+
+	ctx := FieldContext{Path: path, Key: node.start.Name.Local, Value: xmlNodeValue(node)}
+	if replaced, ok := s.applyRules(ctx); ok {
+		node.content = nil
+		if replaced != nil {
+			node.content = []any{xml.CharData(toString(replaced))}
+		}
+		return
+	}
+	s.scrubXMLContent(node.content, path)
+}
+
+// xmlNodeValue returns the text of a leaf element, or nil when it has child elements.
+func xmlNodeValue(node *xmlNode) any {
+	var text strings.Builder
+	for _, item := range node.content {
+		switch t := item.(type) {
+		case *xmlNode:
+			return nil
+		case xml.CharData:
+			text.Write(t)
+		}
+	}
+	return text.String()
+}
+
+// Text keeps its whitespace as is; only the characters that would break the markup are
+// escaped.
+var (
+	xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;")
+)
+
+func writeXMLContent(buf *bytes.Buffer, content []any) {
+	for _, item := range content {
+		switch t := item.(type) {
+		case *xmlNode:
+			buf.WriteByte('<')
+			buf.WriteString(qualifiedName(t.start.Name))
+			for _, attr := range t.start.Attr {
+				buf.WriteByte(' ')
+				buf.WriteString(qualifiedName(attr.Name))
+				buf.WriteString(`="`)
+				xmlAttrEscaper.WriteString(buf, attr.Value)
+				buf.WriteByte('"')
+			}
+			buf.WriteByte('>')
+			writeXMLContent(buf, t.content)
+			buf.WriteString("</")
+			buf.WriteString(qualifiedName(t.start.Name))
+			buf.WriteByte('>')
+		case xml.CharData:
+			xmlTextEscaper.WriteString(buf, string(t))
+		case xml.Comment:
+			buf.WriteString("<!--")
+			buf.Write(t)
+			buf.WriteString("-->")
+		case xml.ProcInst:
+			buf.WriteString("<?")
+			buf.WriteString(t.Target)
+			if len(t.Inst) > 0 {
+				buf.WriteByte(' ')
+				buf.Write(t.Inst)
+			}
+			buf.WriteString("?>")
+		case xml.Directive:
+			buf.WriteString("<!")
+			buf.Write(t)
+			buf.WriteByte('>')
+		}
+	}
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
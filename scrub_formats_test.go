@@ -0,0 +1,181 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+const soapLogin = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <!-- login -->
+  <soap:Body>
+    <Login user="alice" token="abc">
+      <Password>hunter2</Password>
+      <Note>a &amp; b</Note>
+      <Session><Id>1</Id><Ttl>60</Ttl></Session>
+    </Login>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestScrubXML(t *testing.T) {
+	s := NewScrubber(WithRules(NewRule("card", MatchPathInsensitive("envelope.body.login.@user"), MaskString('*', 1, 0))))
+
+	out, err := s.ScrubXML([]byte(soapLogin))
+	if err != nil {
+		t.Fatalf("ScrubXML returned error: %v", err)
+	}
+	got := string(out)
+
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">`,
+		`<!-- login -->`,
+		`<Login user="a****" token="[REDACTED]">`,
+		`<Password>[REDACTED]</Password>`,
+		`<Note>a &amp; b</Note>`,
+		`<Session>[REDACTED]</Session>`,
+		"</soap:Body>\n</soap:Envelope>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestScrubXMLRejectsMalformedDocuments(t *testing.T) {
+	for _, doc := range []string{"<a><b></a>", "<a>", "<a></a></b>"} {
+		if _, err := NewScrubber().ScrubXML([]byte(doc)); err == nil {
+			t.Fatalf("expected error for %q", doc)
+		}
+	}
+}
+
+func TestScrubForm(t *testing.T) {
+	out, err := NewScrubber().ScrubForm([]byte("user=alice&password=hunter2&token=a&token=b"))
+	if err != nil {
+		t.Fatalf("ScrubForm returned error: %v", err)
+	}
+	if got := string(out); got != "password=%5BREDACTED%5D&token=%5BREDACTED%5D&token=%5BREDACTED%5D&user=alice" {
+		t.Fatalf("unexpected scrubbed form: %s", got)
+	}
+	if _, err := NewScrubber().ScrubForm([]byte("a=%zz")); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
+
+func TestSniffContentType(t *testing.T) {
+	cases := map[string]string{
+		`{"a":1}`:       ContentTypeJSON,
+		` "text" `:      ContentTypeJSON,
+		"<a/>":          ContentTypeXML,
+		"a=1&b=2":       ContentTypeForm,
+		"plain message": "",
+		"":              "",
+	}
+	for input, want := range cases {
+		if got := SniffContentType([]byte(input)); got != want {
+			t.Fatalf("SniffContentType(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestScrubContentDispatch(t *testing.T) {
+	s := NewScrubber()
+	cases := []struct {
+		contentType string
+		input       string
+		want        string
+	}{
+		{"application/soap+xml; charset=utf-8", "<token>a</token>", "<token>[REDACTED]</token>"},
+		{"text/xml", "<token>a</token>", "<token>[REDACTED]</token>"},
+		{"application/problem+json", `{"token":"a"}`, `{"token":"[REDACTED]"}`},
+		{"application/x-www-form-urlencoded", "token=a", "token=%5BREDACTED%5D"},
+		{"", "token=a", "token=%5BREDACTED%5D"},
+	}
+	for _, tc := range cases {
+		out, err := s.ScrubContent(tc.contentType, []byte(tc.input))
+		if err != nil {
+			t.Fatalf("ScrubContent(%q) returned error: %v", tc.contentType, err)
+		}
+		if string(out) != tc.want {
+			t.Fatalf("ScrubContent(%q) = %s, want %s", tc.contentType, out, tc.want)
+		}
+	}
+	if _, err := s.ScrubContent("text/plain", []byte("x")); !errors.Is(err, ErrUnsupportedContent) {
+		t.Fatalf("expected ErrUnsupportedContent, got %v", err)
+	}
+}
+
+func TestWithScrubberPicksPayloadFormat(t *testing.T) {
+	ctx := context.Background()
+	var stored Record
+	storage := stubStorage{saveFn: func(_ context.Context, record Record) error {
+		stored = record
+		return nil
+	}}
+
+	rec := New(storage, WithScrubber(NewScrubber()))
+	if err := rec.RecordRequest(ctx, nil, "req", []byte("<Login><Password>x</Password></Login>"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if string(stored.Payload) != "<Login><Password>[REDACTED]</Password></Login>" {
+		t.Fatalf("expected sniffed XML to be scrubbed, got %s", stored.Payload)
+	}
+
+	rec = New(storage, WithScrubber(NewScrubber(), ScrubberContentType(ContentTypeForm), ScrubberFailOnError()))
+	if err := rec.RecordResponse(ctx, nil, "req", []byte("secret=x"), nil); err != nil {
+		t.Fatalf("RecordResponse returned error: %v", err)
+	}
+	if string(stored.Payload) != "secret=%5BREDACTED%5D" {
+		t.Fatalf("expected form payload to be scrubbed, got %s", stored.Payload)
+	}
+	if err := rec.RecordResponse(ctx, nil, "req", []byte("a=%zz"), nil); err == nil {
+		t.Fatal("expected failOnError to surface the decode error")
+	}
+}
+
+func TestWithScrubberFallsBackToText(t *testing.T) {
+	ctx := context.Background()
+	var stored Record
+	storage := stubStorage{saveFn: func(_ context.Context, record Record) error {
+		stored = record
+		return nil
+	}}
+	var logs bytes.Buffer
+	metrics := NewMetrics()
+	rec := New(storage,
+		WithScrubber(NewScrubber(), ScrubberContentType(ContentTypeJSON)),
+		WithLogger(NewSlogLogger(slog.New(slog.NewTextHandler(&logs, nil)))),
+		WithMetrics(metrics),
+	)
+
+	payload := []byte(`{"password":"hunter2","card":"4111 1111 1111 1111"`)
+	if err := rec.RecordRequest(ctx, nil, "req", payload, nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if strings.Contains(string(stored.Payload), "4111 1111 1111 1111") {
+		t.Fatalf("expected the malformed payload to be scrubbed as text, got %s", stored.Payload)
+	}
+	key := metricKey(ScrubErrorsMetricName, map[string]string{"type": string(RecordTypeRequest)})
+	if got := metrics.GetCounters()[key]; got != 1 {
+		t.Fatalf("expected 1 scrub error, got %d (%v)", got, metrics.GetCounters())
+	}
+	if !strings.Contains(logs.String(), "scrubbed as plain text") {
+		t.Fatalf("expected a warning, got %q", logs.String())
+	}
+
+	rec = New(storage, WithScrubber(NewScrubber(), ScrubberContentType("text/plain")), WithMetrics(metrics))
+	if err := rec.RecordRequest(ctx, nil, "req", []byte("Authorization: Bearer s3cr3t-token"), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if strings.Contains(string(stored.Payload), "s3cr3t-token") {
+		t.Fatalf("expected an unsupported payload to be scrubbed as text, got %s", stored.Payload)
+	}
+	if got := metrics.GetCounters()[key]; got != 2 {
+		t.Fatalf("expected 2 scrub errors, got %d", got)
+	}
+}
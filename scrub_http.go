@@ -0,0 +1,207 @@
+package recorder
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Path elements used for the parts of a URL.
+const (
+	URLQueryPath    = "query"
+	URLFragmentPath = "fragment"
+	URLUserinfoPath = "userinfo"
+)
+
+// urlHeaders hold URLs whose query strings are scrubbed by ScrubHeader and
+// ScrubRawHeaders when the header itself is not matched.
+var urlHeaders = map[string]bool{
+	"Location":         true,
+	"Content-Location": true,
+	"Referer":          true,
+}
+
+// ScrubURL applies the rules to the query parameters and userinfo of a URL and
+// reassembles it. Query parameters are matched with the path query.<name> and the key
+// <name>; a fragment made of parameters, as used by OAuth implicit flows, with
+// fragment.<name>. The username and password use userinfo.username and
+// userinfo.password. Parameter order is kept and a parameter replaced with nil keeps its
+// name with an empty value.
+func (s *Scrubber) ScrubURL(raw string) (string, error) {
+	if s == nil || raw == "" {
+		return raw, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("scrubber: parse url: %w", err)
+	}
+	s.scrubURL(u)
+	return u.String(), nil
+}
+
+func (s *Scrubber) scrubURL(u *url.URL) {
+	if u.User != nil {
+		username := u.User.Username()
+		if replaced, ok := s.applyRules(FieldContext{Path: []string{URLUserinfoPath, "username"}, Key: "username", Value: username}); ok {
+			username = toString(replaced)
+		}
+		if password, set := u.User.Password(); set {
+			if replaced, ok := s.applyRules(FieldContext{Path: []string{URLUserinfoPath, "password"}, Key: "password", Value: password}); ok {
+				password = toString(replaced)
+			}
+			u.User = url.UserPassword(username, password)
+		} else {
+			u.User = url.User(username)
+		}
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = s.scrubQuery(u.RawQuery, URLQueryPath)
+	}
+	if fragment := u.EscapedFragment(); strings.Contains(fragment, "=") {
+		scrubbed := s.scrubQuery(fragment, URLFragmentPath)
+		if unescaped, err := url.PathUnescape(scrubbed); err == nil {
+			u.Fragment, u.RawFragment = unescaped, scrubbed
+		}
+	}
+}
+
+// ScrubQuery scrubs a raw query string such as "a=1&access_token=x", keeping the order of
+// the parameters. Parameters are matched with the path query.<name>.
+func (s *Scrubber) ScrubQuery(rawQuery string) string {
+	if s == nil || rawQuery == "" {
+		return rawQuery
+	}
+	return s.scrubQuery(rawQuery, URLQueryPath)
+}
+
+func (s *Scrubber) scrubQuery(rawQuery, root string) string {
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			value = rawValue
+		}
+		if replaced, ok := s.applyRules(FieldContext{Path: []string{root, key}, Key: key, Value: value}); ok {
+			pairs[i] = rawKey + "=" + url.QueryEscape(toString(replaced))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// ScrubHeader returns a scrubbed copy of h. Headers are matched by their canonical name
+// with a single-element path, like the keys of a form, and repeated values get "[i]"
+// path elements. URLs in Location, Content-Location and Referer headers have their query
+// strings scrubbed.
+func (s *Scrubber) ScrubHeader(h http.Header) http.Header {
+	if s == nil || h == nil {
+		return h.Clone()
+	}
+	scrubbed, ok := s.scrubValue(map[string][]string(h), nil).(map[string][]string)
+	if !ok {
+		return h.Clone()
+	}
+	for name, values := range scrubbed {
+		if !urlHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		original := h[name]
+		for i, value := range values {
+			if i >= len(original) || value != original[i] {
+				continue // replaced by a rule
+			}
+			if cleaned, err := s.ScrubURL(value); err == nil {
+				values[i] = cleaned
+			}
+		}
+	}
+	return http.Header(scrubbed)
+}
+
+// ScrubRawHeaders scrubs a raw HTTP header block, e.g. a dumped request or response,
+// keeping the order of the lines and their endings. An optional start line is kept, with
+// the query of a request target scrubbed. Folded continuation lines belong to the header
+// above them. Anything after the blank line ending the header block is returned as is.
+func (s *Scrubber) ScrubRawHeaders(data []byte) ([]byte, error) {
+	if s == nil || len(data) == 0 {
+		return append([]byte(nil), data...), nil
+	}
+	var out bytes.Buffer
+	out.Grow(len(data))
+
+	rest := data
+	dropFolded := false
+	for lineNo := 0; len(rest) > 0; lineNo++ {
+		line, ending := rest, []byte(nil)
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, ending, rest = rest[:i], rest[i:i+1], rest[i+1:]
+			if len(line) > 0 && line[len(line)-1] == '\r' {
+				line, ending = line[:len(line)-1], []byte("\r\n")
+			}
+		} else {
+			rest = nil
+		}
+
+		switch {
+		case len(line) == 0:
+			out.Write(ending)
+			out.Write(rest)
+			return out.Bytes(), nil
+		case line[0] == ' ' || line[0] == '\t':
+			if !dropFolded {
+				out.Write(line)
+				out.Write(ending)
+			}
+			continue
+		}
+
+		name, value, found := bytes.Cut(line, []byte(":"))
+		if !found || bytes.ContainsAny(name, " \t") {
+			if lineNo > 0 {
+				return nil, fmt.Errorf("scrubber: malformed header line %d", lineNo+1)
+			}
+			out.Write(s.scrubStartLine(line))
+			out.Write(ending)
+			continue
+		}
+
+		key := http.CanonicalHeaderKey(string(name))
+		trimmed := strings.TrimSpace(string(value))
+		dropFolded = false
+		if replaced, ok := s.applyRules(FieldContext{Path: []string{key}, Key: key, Value: trimmed}); ok {
+			value, dropFolded = []byte(" "+toString(replaced)), true
+		} else if urlHeaders[key] {
+			if cleaned, err := s.ScrubURL(trimmed); err == nil && cleaned != trimmed {
+				value = []byte(" " + cleaned)
+			}
+		}
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(value)
+		out.Write(ending)
+	}
+	return out.Bytes(), nil
+}
+
+// scrubStartLine scrubs the request target of "METHOD target HTTP/x.y"; status lines are
+// returned as is.
+func (s *Scrubber) scrubStartLine(line []byte) []byte {
+	parts := strings.SplitN(string(line), " ", 3)
+	if len(parts) != 3 || !strings.HasPrefix(parts[2], "HTTP/") {
+		return line
+	}
+	target, err := s.ScrubURL(parts[1])
+	if err != nil {
+		return line
+	}
+	return []byte(parts[0] + " " + target + " " + parts[2])
+}
@@ -0,0 +1,192 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tags and metrics written for scrub reports, see ScrubberReportTags and
+// ScrubberReportMetrics.
+const (
+	ScrubbedRulesTag       = "scrubbed_rules"
+	ScrubbedPathsTag       = "scrubbed_paths"
+	ScrubbedRecordTypeTag  = "scrubbed_record_type"
+	ScrubMatchesMetricName = "recorder.scrub.matches"
+)
+
+// ScrubMatch records that a rule rewrote the field at Path. Values are never included.
+type ScrubMatch struct {
+	Path string `json:"path"`
+	Rule string `json:"rule"`
+}
+
+// ScrubReport lists the fields a scrubber rewrote, in the order they were visited.
+type ScrubReport struct {
+	Matches []ScrubMatch `json:"matches"`
+}
+
+// Len returns the number of matches; it is safe to call on a nil report.
+func (r *ScrubReport) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.Matches)
+}
+
+// Rules returns the sorted names of the rules that fired.
+func (r *ScrubReport) Rules() []string {
+	return r.unique(func(m ScrubMatch) string { return m.Rule })
+}
+
+// Paths returns the sorted paths that were rewritten.
+func (r *ScrubReport) Paths() []string {
+	return r.unique(func(m ScrubMatch) string { return m.Path })
+}
+
+func (r *ScrubReport) unique(field func(ScrubMatch) string) []string {
+	if r.Len() == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(r.Matches))
+	out := make([]string, 0, len(r.Matches))
+	for _, m := range r.Matches {
+		v := field(m)
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (r *ScrubReport) add(path []string, rule string) {
+	r.Matches = append(r.Matches, ScrubMatch{Path: strings.Join(path, "."), Rule: rule})
+}
+
+// Audited returns a copy of the scrubber that records every rule it applies in the
+// returned report. Plain-text payloads are reported with an empty path and the name of
+// the detector. The copy uses the rules current at the time of the call and is meant
+// for a single payload; it is not safe for concurrent use.
+func (s *Scrubber) Audited() (*Scrubber, *ScrubReport) {
+	report := &ScrubReport{}
+	if s == nil {
+		return nil, report
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &Scrubber{
+		rules:              s.rules,
+		detectors:          s.detectors,
+		defaultReplacement: s.defaultReplacement,
+		includeDefaults:    s.includeDefaults,
+		report:             report,
+	}, report
+}
+
+// ScrubContentWithReport is ScrubContent returning a report of the matched paths and
+// rule names alongside the sanitized payload.
+func (s *Scrubber) ScrubContentWithReport(contentType string, data []byte) ([]byte, *ScrubReport, error) {
+	audited, report := s.Audited()
+	scrubbed, err := audited.ScrubContent(contentType, data)
+	return scrubbed, report, err
+}
+
+type scrubAuditConfig struct {
+	tags    bool
+	record  bool
+	metrics bool
+}
+
+// ScrubberReportTags adds the ScrubbedRulesTag and ScrubbedPathsTag tags, holding the
+// comma-separated rule names and paths, to records the scrubber changed.
+func ScrubberReportTags() ScrubberBindingOption {
+	return func(cfg *scrubberBindingConfig) {
+		cfg.audit.tags = true
+	}
+}
+
+// ScrubberReportRecord saves the ScrubReport of every record the scrubber changed as a
+// JSON sidecar record of type RecordTypeScrubReport, under ScrubReportID. The sidecar is
+// tagged with ScrubbedRecordTypeTag, the type of the scrubbed record.
+func ScrubberReportRecord() ScrubberBindingOption {
+	return func(cfg *scrubberBindingConfig) {
+		cfg.audit.record = true
+	}
+}
+
+// ScrubberReportMetrics increments ScrubMatchesMetricName for every match, tagged with
+// the record type and the rule name.
+func ScrubberReportMetrics() ScrubberBindingOption {
+	return func(cfg *scrubberBindingConfig) {
+		cfg.audit.metrics = true
+	}
+}
+
+// ScrubReportID is the request ID under which the scrub report of a record is saved, so
+// that the reports of the request and the response of one exchange do not collide.
+func ScrubReportID(recordType RecordType, requestID string) string {
+	return requestID + "." + string(recordType)
+}
+
+type scrubReportPayload struct {
+	RecordType RecordType   `json:"record_type"`
+	RequestID  string       `json:"request_id"`
+	Matches    []ScrubMatch `json:"matches"`
+}
+
+func (a *scrubAuditConfig) enabled() bool {
+	return a != nil && (a.tags || a.record || a.metrics)
+}
+
+// applyReport adds the report tags to a record before it is saved.
+func (a *scrubAuditConfig) applyReport(record *Record) {
+	if !a.tags || record.scrubReport.Len() == 0 {
+		return
+	}
+	tags := cloneTags(record.Tags)
+	if tags == nil {
+		tags = make(map[string]string, 2)
+	}
+	tags[ScrubbedRulesTag] = strings.Join(record.scrubReport.Rules(), ",")
+	tags[ScrubbedPathsTag] = strings.Join(record.scrubReport.Paths(), ",")
+	record.Tags = tags
+}
+
+// publishReport counts and persists the report of a saved record.
+func (r *baseRecorder) publishReport(ctx context.Context, audit *scrubAuditConfig, record Record) {
+	report := record.scrubReport
+	if report.Len() == 0 {
+		return
+	}
+	if audit.metrics {
+		for _, m := range report.Matches {
+			r.metrics.IncrementCounter(ScrubMatchesMetricName, map[string]string{"type": string(record.Type), "rule": m.Rule})
+		}
+	}
+	if !audit.record {
+		return
+	}
+	payload, err := json.Marshal(scrubReportPayload{RecordType: record.Type, RequestID: record.RequestID, Matches: report.Matches})
+	if err == nil {
+		sidecar := Record{
+			Type:        RecordTypeScrubReport,
+			PrimaryID:   record.PrimaryID,
+			RequestID:   ScrubReportID(record.Type, record.RequestID),
+			Payload:     payload,
+			Tags:        map[string]string{ScrubbedRecordTypeTag: string(record.Type)},
+			Timestamp:   time.Now(),
+			ContentType: ContentTypeJSON,
+		}
+		start := time.Now()
+		err = r.storage.Save(contextWithRecord(ctx, sidecar.Type, sidecar.RequestID, sidecar.PrimaryID), sidecar)
+		r.observe("save", map[string]string{"type": string(sidecar.Type)}, start, err)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).Warn("failed to save scrub report", "error", err)
+	}
+}
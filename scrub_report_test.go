@@ -0,0 +1,126 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestScrubContentWithReport(t *testing.T) {
+	scrub := NewScrubber(WithRules(
+		NewRule("card", MatchPathGlob("items[*].card"), MaskString('*', 0, 4)),
+	))
+	out, report, err := scrub.ScrubContentWithReport(ContentTypeJSON, []byte(`{"password":"hunter2","items":[{"card":"4111111111111111"}],"name":"jane"}`))
+	if err != nil {
+		t.Fatalf("ScrubContentWithReport returned error: %v", err)
+	}
+	if strings.Contains(string(out), "hunter2") {
+		t.Fatalf("expected payload to be scrubbed, got %s", out)
+	}
+
+	want := []ScrubMatch{{Path: "items.[0].card", Rule: "card"}, {Path: "password", Rule: "default-sensitive"}}
+	got := append([]ScrubMatch(nil), report.Matches...)
+	sort.Slice(got, func(i, j int) bool { return got[i].Path < got[j].Path })
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected matches %+v", report.Matches)
+	}
+	if rules := report.Rules(); !reflect.DeepEqual(rules, []string{"card", "default-sensitive"}) {
+		t.Fatalf("unexpected rules %v", rules)
+	}
+	encoded, _ := json.Marshal(report)
+	if strings.Contains(string(encoded), "4111") || strings.Contains(string(encoded), "hunter2") {
+		t.Fatalf("report must not contain values: %s", encoded)
+	}
+}
+
+func TestAuditedDoesNotReportOnOriginal(t *testing.T) {
+	scrub := NewScrubber(WithDetectors(DetectEmails()))
+	audited, report := scrub.Audited()
+
+	if _, err := audited.ScrubContent("text/plain", []byte("contact jane@example.com")); err != nil {
+		t.Fatalf("ScrubContent returned error: %v", err)
+	}
+	if len(report.Matches) != 1 || report.Matches[0] != (ScrubMatch{Path: "", Rule: "email"}) {
+		t.Fatalf("expected detector match for plain text, got %+v", report.Matches)
+	}
+
+	scrub.ScrubMap(map[string]any{"password": "x"})
+	if len(report.Matches) != 1 {
+		t.Fatalf("expected original scrubber not to report, got %+v", report.Matches)
+	}
+	if (*ScrubReport)(nil).Len() != 0 {
+		t.Fatal("expected nil report to be empty")
+	}
+}
+
+func TestScrubberReportTagsRecordAndMetrics(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	metrics := NewMetrics()
+	rec := New(storage,
+		WithMetrics(metrics),
+		WithScrubber(NewScrubber(), ScrubberReportTags(), ScrubberReportRecord(), ScrubberReportMetrics()),
+	)
+
+	if err := rec.RecordRequest(ctx, nil, "req-1", []byte(`{"password":"p","token":"t","amount":5}`), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if err := rec.RecordResponse(ctx, nil, "req-1", []byte(`{"status":"ok"}`), nil); err != nil {
+		t.Fatalf("RecordResponse returned error: %v", err)
+	}
+
+	saved := storage.records[RecordTypeRequest]["req-1"]
+	if saved.Tags[ScrubbedRulesTag] != "default-sensitive" || saved.Tags[ScrubbedPathsTag] != "password,token" {
+		t.Fatalf("unexpected report tags %v", saved.Tags)
+	}
+	if _, ok := storage.records[RecordTypeResponse]["req-1"].Tags[ScrubbedRulesTag]; ok {
+		t.Fatal("expected no report tags on an unchanged record")
+	}
+
+	sidecar, ok := storage.records[RecordTypeScrubReport][ScrubReportID(RecordTypeRequest, "req-1")]
+	if !ok {
+		t.Fatalf("expected scrub report record, got %v", storage.records[RecordTypeScrubReport])
+	}
+	var payload struct {
+		RecordType RecordType   `json:"record_type"`
+		RequestID  string       `json:"request_id"`
+		Matches    []ScrubMatch `json:"matches"`
+	}
+	if err := json.Unmarshal(sidecar.Payload, &payload); err != nil {
+		t.Fatalf("invalid report payload: %v", err)
+	}
+	if payload.RecordType != RecordTypeRequest || payload.RequestID != "req-1" || len(payload.Matches) != 2 {
+		t.Fatalf("unexpected report payload %+v", payload)
+	}
+	if len(storage.records[RecordTypeScrubReport]) != 1 {
+		t.Fatalf("expected a single report, got %v", storage.records[RecordTypeScrubReport])
+	}
+	if got := sidecar.Tags[ScrubbedRecordTypeTag]; got != string(RecordTypeRequest) {
+		t.Fatalf("expected %s tag %q, got %v", ScrubbedRecordTypeTag, RecordTypeRequest, sidecar.Tags)
+	}
+	saves := metricKey("recorder.storage.save.success", map[string]string{"type": string(RecordTypeScrubReport)})
+	if got := metrics.GetCounters()[saves]; got != 1 {
+		t.Fatalf("expected the report save to be observed, got %d (%v)", got, metrics.GetCounters())
+	}
+
+	key := metricKey(ScrubMatchesMetricName, map[string]string{"type": "request", "rule": "default-sensitive"})
+	if got := metrics.GetCounters()[key]; got != 2 {
+		t.Fatalf("expected 2 counted matches, got %d (%v)", got, metrics.GetCounters())
+	}
+}
+
+func TestScrubberReportForValues(t *testing.T) {
+	storage := newMemoryStorage()
+	rec := New(storage, WithScrubber(NewScrubber(), ScrubberReportTags()))
+
+	value := map[string]any{"user": map[string]any{"password": "p"}}
+	if err := rec.RecordValue(context.Background(), RecordTypeRequest, nil, "req-2", value, nil); err != nil {
+		t.Fatalf("RecordValue returned error: %v", err)
+	}
+	if got := storage.records[RecordTypeRequest]["req-2"].Tags[ScrubbedPathsTag]; got != "user.password" {
+		t.Fatalf("expected value report tags, got %q", got)
+	}
+}
@@ -0,0 +1,182 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxStreamDepth bounds the nesting accepted by ScrubJSONStream.
+const maxStreamDepth = 1000
+
+// ScrubJSONStream scrubs JSON read from src token by token and writes the result to dst.
+// Unlike ScrubJSON it keeps object keys in their original order and numbers as written,
+// and it holds only the current path and token in memory, so payloads of any size can be
+// processed. A sequence of values, such as newline-delimited JSON, is written one value
+// per line. Output is compact.
+//
+// Rules see numbers as json.Number. Objects and arrays are not buffered, so rules see
+// them with a nil Value; a matching rule replaces the whole object or array.
+func (s *Scrubber) ScrubJSONStream(dst io.Writer, src io.Reader) error {
+	if s == nil {
+		_, err := io.Copy(dst, src)
+		return err
+	}
+	decoder := json.NewDecoder(src)
+	decoder.UseNumber()
+	w := bufio.NewWriter(dst)
+	st := &jsonStream{s: s, dec: decoder, w: w}
+	st.enc = json.NewEncoder(&st.buf)
+	st.enc.SetEscapeHTML(false)
+
+	for first := true; ; first = false {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("scrubber: decode json: %w", err)
+		}
+		if !first {
+			w.WriteByte('\n')
+		}
+		if err := st.value(tok, nil, 0); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("scrubber: write json: %w", err)
+	}
+	return nil
+}
+
+// ScrubJSONOrdered is ScrubJSONStream for in-memory payloads.
+func (s *Scrubber) ScrubJSONOrdered(data []byte) ([]byte, error) {
+	if s == nil || len(bytes.TrimSpace(data)) == 0 {
+		return append([]byte(nil), data...), nil
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(data))
+	if err := s.ScrubJSONStream(&buf, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type jsonStream struct {
+	s   *Scrubber
+	dec *json.Decoder
+	w   *bufio.Writer
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+func (st *jsonStream) value(tok json.Token, path []string, depth int) error {
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return st.write(tok)
+	}
+	if depth >= maxStreamDepth {
+		return fmt.Errorf("scrubber: decode json: nesting deeper than %d", maxStreamDepth)
+	}
+
+	closing := byte('}')
+	if delim == '[' {
+		closing = ']'
+	}
+	st.w.WriteByte(byte(delim))
+	for i := 0; st.dec.More(); i++ {
+		if i > 0 {
+			st.w.WriteByte(',')
+		}
+		var key string
+		if delim == '{' {
+			keyTok, err := st.token()
+			if err != nil {
+				return err
+			}
+			key, _ = keyTok.(string)
+			if err := st.write(key); err != nil {
+				return err
+			}
+			st.w.WriteByte(':')
+		} else {
+			key = "[" + strconv.Itoa(i) + "]"
+		}
+		valueTok, err := st.token()
+		if err != nil {
+			return err
+		}
+		if err := st.field(valueTok, appendPath(path, key), key, depth); err != nil {
+			return err
+		}
+	}
+	if _, err := st.token(); err != nil { // closing delimiter
+		return err
+	}
+	return st.w.WriteByte(closing)
+}
+
+func (st *jsonStream) field(tok json.Token, path []string, key string, depth int) error {
+	ctx := FieldContext{Path: path, Key: key, Value: tok}
+	_, composite := tok.(json.Delim)
+	if composite {
+		ctx.Value = nil
+	}
+	if replaced, ok := st.s.applyRules(ctx); ok {
+		if composite {
+			if err := st.skip(); err != nil {
+				return err
+			}
+		}
+		return st.write(replaced)
+	}
+	return st.value(tok, path, depth+1)
+}
+
+// skip consumes the rest of the object or array whose opening delimiter was just read.
+func (st *jsonStream) skip() error {
+	for level := 1; level > 0; {
+		tok, err := st.token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			level++
+		case json.Delim('}'), json.Delim(']'):
+			level--
+		}
+	}
+	return nil
+}
+
+func (st *jsonStream) token() (json.Token, error) {
+	tok, err := st.dec.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("scrubber: decode json: %w", err)
+	}
+	return tok, nil
+}
+
+// write encodes a scalar token or a replacement value. HTML characters are not escaped so
+// that unchanged strings keep their meaning byte for byte.
+func (st *jsonStream) write(v any) error {
+	if n, ok := v.(json.Number); ok {
+		_, err := st.w.WriteString(string(n))
+		return err
+	}
+	st.buf.Reset()
+	if err := st.enc.Encode(v); err != nil {
+		return fmt.Errorf("scrubber: encode json: %w", err)
+	}
+	_, err := st.w.Write(bytes.TrimSuffix(st.buf.Bytes(), []byte("\n")))
+	return err
+}
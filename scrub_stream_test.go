@@ -0,0 +1,103 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestScrubJSONOrderedKeepsOrderAndNumbers(t *testing.T) {
+	scrub := NewScrubber(WithRules(
+		NewRule("card", MatchPathGlob("items[*].card"), MaskString('*', 0, 4)),
+	))
+	in := `{"zeta":1,"id":12345678901234567890,"amount":10.50,"password":"hunter2",` +
+		`"items":[{"card":"4111111111111111","note":"<b>&</b>"},{"card":4111111111111111}],"alpha":null,"ok":true}`
+
+	out, err := scrub.ScrubJSONOrdered([]byte(in))
+	if err != nil {
+		t.Fatalf("ScrubJSONOrdered returned error: %v", err)
+	}
+	want := `{"zeta":1,"id":12345678901234567890,"amount":10.50,"password":"[REDACTED]",` +
+		`"items":[{"card":"************1111","note":"<b>&</b>"},{"card":"************1111"}],"alpha":null,"ok":true}`
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n got %s\nwant %s", out, want)
+	}
+}
+
+func TestScrubJSONStreamReplacesCompositeValues(t *testing.T) {
+	scrub := NewScrubber()
+	in := `{"auth":{"user":"u","nested":[1,{"x":[2]}]},"keep":[1, 2]}`
+
+	var out bytes.Buffer
+	if err := scrub.ScrubJSONStream(&out, strings.NewReader(in)); err != nil {
+		t.Fatalf("ScrubJSONStream returned error: %v", err)
+	}
+	if out.String() != `{"auth":"[REDACTED]","keep":[1,2]}` {
+		t.Fatalf("unexpected output %s", out.String())
+	}
+}
+
+func TestScrubJSONStreamNDJSONAndErrors(t *testing.T) {
+	scrub := NewScrubber()
+
+	var out bytes.Buffer
+	if err := scrub.ScrubJSONStream(&out, strings.NewReader("{\"token\":\"a\"}\n{\"token\":\"b\"}\n")); err != nil {
+		t.Fatalf("ScrubJSONStream returned error: %v", err)
+	}
+	if out.String() != "{\"token\":\"[REDACTED]\"}\n{\"token\":\"[REDACTED]\"}" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	for _, in := range []string{`{"a":`, `{"a" 1}`, `[1,2`} {
+		if err := scrub.ScrubJSONStream(io.Discard, strings.NewReader(in)); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+	if err := scrub.ScrubJSONStream(io.Discard, strings.NewReader(strings.Repeat("[", maxStreamDepth+1))); err == nil {
+		t.Error("expected error for excessive nesting")
+	}
+}
+
+func TestScrubJSONStreamLargePayload(t *testing.T) {
+	scrub := NewScrubber()
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("["))
+		for i := 0; i < 20000; i++ {
+			if i > 0 {
+				pw.Write([]byte(","))
+			}
+			fmt.Fprintf(pw, `{"id":%d,"password":"p%d","payload":"%s"}`, i, i, strings.Repeat("x", 64))
+		}
+		pw.Write([]byte("]"))
+		pw.Close()
+	}()
+
+	var out bytes.Buffer
+	if err := scrub.ScrubJSONStream(&out, pr); err != nil {
+		t.Fatalf("ScrubJSONStream returned error: %v", err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid output: %v", err)
+	}
+	if len(decoded) != 20000 || decoded[19999]["password"] != "[REDACTED]" {
+		t.Fatalf("unexpected result: %d items, last %v", len(decoded), decoded[len(decoded)-1])
+	}
+}
+
+func TestScrubberOrderedJSONBinding(t *testing.T) {
+	storage := newMemoryStorage()
+	rec := New(storage, WithScrubber(NewScrubber(), ScrubberOrderedJSON()))
+
+	if err := rec.RecordRequest(context.Background(), nil, "req-1", []byte(`{"b":1.0,"token":"t","a":2}`), nil); err != nil {
+		t.Fatalf("RecordRequest returned error: %v", err)
+	}
+	if got := string(storage.records[RecordTypeRequest]["req-1"].Payload); got != `{"b":1.0,"token":"[REDACTED]","a":2}` {
+		t.Fatalf("unexpected payload %s", got)
+	}
+}
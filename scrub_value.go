@@ -0,0 +1,154 @@
+package recorder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxScrubDepth stops the reflective walk on self-referencing values.
+const maxScrubDepth = 64
+
+// ScrubValue returns a scrubbed copy of value without serializing it first. Structs,
+// pointers, string-keyed maps, slices and arrays are copied and walked; struct fields are
+// matched by their json name, or the Go field name when there is none. A replacement that
+// does not fit the field type is converted to a string for string fields and clears any
+// other field. value itself is never modified.
+func ScrubValue[T any](s *Scrubber, value T) T {
+	if s == nil {
+		return value
+	}
+	scrubbed, ok := s.scrubTyped(value).(T)
+	if !ok {
+		return value
+	}
+	return scrubbed
+}
+
+func (s *Scrubber) scrubTyped(value any) any {
+	if s == nil || value == nil {
+		return value
+	}
+	switch value.(type) {
+	case map[string]any, map[string]string, map[string][]string, []string, []any:
+		return s.scrubValue(value, nil)
+	}
+	return s.scrubReflect(reflect.ValueOf(value), nil, 0).Interface()
+}
+
+func (s *Scrubber) scrubReflect(v reflect.Value, path []string, depth int) reflect.Value {
+	if depth > maxScrubDepth {
+		return v
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(s.scrubReflect(v.Elem(), path, depth+1))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(s.scrubReflect(v.Elem(), path, depth+1))
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, tagged := structFieldName(field)
+			if field.Anonymous && !tagged {
+				// Embedded structs are flattened by encoders, so keep the parent path.
+				out.Field(i).Set(s.scrubReflect(v.Field(i), path, depth+1))
+				continue
+			}
+			out.Field(i).Set(s.scrubField(v.Field(i), appendPath(path, name), name, depth))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			out.SetMapIndex(iter.Key(), s.scrubField(iter.Value(), appendPath(path, key), key, depth))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		s.scrubElements(v, out, path, depth)
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		s.scrubElements(v, out, path, depth)
+		return out
+	default:
+		return v
+	}
+}
+
+func (s *Scrubber) scrubElements(src, dst reflect.Value, path []string, depth int) {
+	for i := 0; i < src.Len(); i++ {
+		key := fmt.Sprintf("[%d]", i)
+		dst.Index(i).Set(s.scrubField(src.Index(i), appendPath(path, key), key, depth))
+	}
+}
+
+func (s *Scrubber) scrubField(v reflect.Value, path []string, key string, depth int) reflect.Value {
+	if replaced, ok := s.applyRules(FieldContext{Path: path, Key: key, Value: v.Interface()}); ok {
+		return coerceReplacement(replaced, v.Type())
+	}
+	return s.scrubReflect(v, path, depth+1)
+}
+
+func coerceReplacement(value any, t reflect.Type) reflect.Value {
+	if value == nil {
+		return reflect.Zero(t)
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Type().AssignableTo(t) {
+		out := reflect.New(t).Elem()
+		out.Set(rv)
+		return out
+	}
+	switch {
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(toString(value)).Convert(t)
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.String:
+		out := reflect.New(t.Elem())
+		out.Elem().SetString(toString(value))
+		return out
+	default:
+		return reflect.Zero(t)
+	}
+}
+
+func structFieldName(field reflect.StructField) (string, bool) {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name, true
+		}
+	}
+	return field.Name, false
+}
+
+// appendPath never shares the backing array of path between siblings.
+func appendPath(path []string, element string) []string {
+	out := make([]string, len(path)+1)
+	copy(out, path)
+	out[len(path)] = element
+	return out
+}
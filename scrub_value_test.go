@@ -0,0 +1,91 @@
+package recorder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type ScrubCredentials struct {
+	Token string
+}
+
+type scrubUser struct {
+	ScrubCredentials
+	Name     string            `json:"name"`
+	Password *string           `json:"password,omitempty"`
+	Secret   int               `json:"secret"`
+	Headers  map[string]string `json:"headers"`
+	Tokens   []string          `json:"tokens"`
+	Created  time.Time         `json:"created"`
+	Raw      []byte            `json:"raw"`
+	Nested   *scrubUser        `json:"nested"`
+	Extra    any               `json:"extra"`
+	internal string
+}
+
+func TestScrubValueCopiesAndScrubsStructs(t *testing.T) {
+	password := "hunter2"
+	created := time.Unix(1700000000, 0)
+	in := &scrubUser{
+		ScrubCredentials: ScrubCredentials{Token: "t-1"},
+		Name:             "alice",
+		Password:         &password,
+		Secret:           42,
+		Headers:          map[string]string{"Authorization": "Bearer x", "Accept": "json"},
+		Tokens:           []string{"a", "b"},
+		Created:          created,
+		Raw:              []byte("raw"),
+		Nested:           &scrubUser{Name: "bob", Password: &password},
+		Extra:            map[string]any{"api_key": "k", "ok": 1},
+		internal:         "kept",
+	}
+
+	out := ScrubValue(NewScrubber(), in)
+
+	if out == in || out.Nested == in.Nested {
+		t.Fatal("expected a deep copy")
+	}
+	if *in.Password != "hunter2" || in.Headers["Authorization"] != "Bearer x" || in.Token != "t-1" {
+		t.Fatal("expected the input to be left untouched")
+	}
+	if out.Token != "[REDACTED]" || *out.Password != "[REDACTED]" || *out.Nested.Password != "[REDACTED]" {
+		t.Fatalf("expected sensitive strings to be replaced, got %+v", out)
+	}
+	if out.Secret != 0 {
+		t.Fatalf("expected non-string sensitive field to be cleared, got %d", out.Secret)
+	}
+	if out.Headers["Authorization"] != "[REDACTED]" || out.Headers["Accept"] != "json" {
+		t.Fatalf("unexpected headers: %v", out.Headers)
+	}
+	if out.Name != "alice" || !out.Created.Equal(created) || string(out.Raw) != "raw" || out.internal != "kept" {
+		t.Fatalf("expected other fields to be preserved, got %+v", out)
+	}
+	if !reflect.DeepEqual(out.Tokens, []string{"a", "b"}) {
+		t.Fatalf("unexpected tokens: %v", out.Tokens)
+	}
+	if extra := out.Extra.(map[string]any); extra["api_key"] != "[REDACTED]" || extra["ok"] != 1 {
+		t.Fatalf("unexpected extra: %v", extra)
+	}
+}
+
+func TestScrubValueMatchesPathsAndIndices(t *testing.T) {
+	s := NewScrubber(WithoutDefaultRules(), WithRules(
+		NewRule("card", MatchPathInsensitive("cards.[1]"), ReplaceWith("***")),
+	))
+	in := struct {
+		Cards [2]string `json:"cards"`
+	}{Cards: [2]string{"1111", "2222"}}
+
+	out := ScrubValue(s, in)
+	if out.Cards != [2]string{"1111", "***"} {
+		t.Fatalf("unexpected cards: %v", out.Cards)
+	}
+}
+
+func TestScrubValueNilScrubber(t *testing.T) {
+	in := scrubUser{Name: "alice"}
+	if out := ScrubValue[scrubUser](nil, in); out.Name != "alice" {
+		t.Fatalf("unexpected value: %+v", out)
+	}
+}
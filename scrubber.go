@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
@@ -29,9 +30,15 @@ type Rule struct {
 type ScrubberOption func(*Scrubber)
 
 type Scrubber struct {
+	// mu guards rules and detectors, which AddRules and config reloads replace while
+	// recorders scrub concurrently.
+	mu                 sync.RWMutex
 	rules              []Rule
+	detectors          []Detector
 	defaultReplacement string
 	includeDefaults    bool
+	// report is set on copies returned by Audited.
+	report *ScrubReport
 }
 
 func NewScrubber(opts ...ScrubberOption) *Scrubber {
@@ -78,6 +85,8 @@ func (s *Scrubber) AddRules(rules ...Rule) {
 	if s == nil || len(rules) == 0 {
 		return
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.rules = append(s.rules, rules...)
 }
 
@@ -198,7 +207,13 @@ func (s *Scrubber) scrubValue(value any, path []string) any {
 	case []any:
 		cloned := make([]any, len(v))
 		for i, val := range v {
-			cloned[i] = s.scrubValue(val, path)
+			key := fmt.Sprintf("[%d]", i)
+			elementPath := appendPath(path, key)
+			if replaced, ok := s.applyRules(FieldContext{Path: elementPath, Key: key, Value: val}); ok {
+				cloned[i] = replaced
+				continue
+			}
+			cloned[i] = s.scrubValue(val, elementPath)
 		}
 		return cloned
 	default:
@@ -207,9 +222,13 @@ func (s *Scrubber) scrubValue(value any, path []string) any {
 }
 
 func (s *Scrubber) applyRules(ctx FieldContext) (any, bool) {
+	s.mu.RLock()
+	rules := s.rules
+	s.mu.RUnlock()
+
 	current := ctx.Value
 	matched := false
-	for _, rule := range s.rules {
+	for _, rule := range rules {
 		matcher := rule.Match
 		if matcher != nil && !matcher(FieldContext{Path: ctx.Path, Key: ctx.Key, Value: current}) {
 			continue
@@ -219,6 +238,9 @@ func (s *Scrubber) applyRules(ctx FieldContext) (any, bool) {
 		}
 		matched = true
 		current = rule.Apply(FieldContext{Path: ctx.Path, Key: ctx.Key, Value: current})
+		if s.report != nil {
+			s.report.add(ctx.Path, rule.Name)
+		}
 		if !rule.Continue {
 			break
 		}
@@ -0,0 +1,362 @@
+package recorder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Match kinds understood by RuleConfig.Match.
+const (
+	MatchKindKey         = "key"
+	MatchKindKeyPrefix   = "key_prefix"
+	MatchKindKeyContains = "key_contains"
+	MatchKindPath        = "path"
+	MatchKindKeyRegexp   = "key_regexp"
+	MatchKindValueRegexp = "value_regexp"
+	MatchKindSecrets     = "secrets"
+)
+
+// Actions understood by RuleConfig.Action.
+const (
+	ActionReplace        = "replace"
+	ActionMask           = "mask"
+	ActionRemove         = "remove"
+	ActionHash           = "hash"
+	ActionPreserveLength = "preserve_length"
+	ActionRedact         = "redact"
+)
+
+// Config formats accepted by ParseScrubberConfig.
+const (
+	ConfigFormatJSON = "json"
+	ConfigFormatYAML = "yaml"
+)
+
+var (
+	matchKinds = []string{MatchKindKey, MatchKindKeyPrefix, MatchKindKeyContains, MatchKindPath, MatchKindKeyRegexp, MatchKindValueRegexp, MatchKindSecrets}
+	actions    = []string{ActionReplace, ActionMask, ActionRemove, ActionHash, ActionPreserveLength, ActionRedact}
+)
+
+// ScrubberConfig is the declarative form of a Scrubber, loaded from JSON or YAML:
+//
+//	default_replacement: "[REDACTED]"
+//	detectors: [jwt, card]
+//	rules:
+//	  - name: card-number
+//	    match: path
+//	    patterns: [payment.card.number]
+//	    action: mask
+//	    keep_end: 4
+//
+// Rules run in file order, before the detectors and the built-in default rules.
+type ScrubberConfig struct {
+	DefaultReplacement  string `json:"default_replacement,omitempty" yaml:"default_replacement,omitempty"`
+	DisableDefaultRules bool   `json:"disable_default_rules,omitempty" yaml:"disable_default_rules,omitempty"`
+	// Detectors names the built-in detectors (see DefaultDetectors) passed to
+	// WithDetectors; "all" selects every one of them.
+	Detectors []string     `json:"detectors,omitempty" yaml:"detectors,omitempty"`
+	Rules     []RuleConfig `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+// RuleConfig describes a single Rule.
+//
+// Match selects the matcher: key, key_prefix and key_contains compare case-insensitively
+// against Patterns, path takes ParsePathPattern patterns such as items[*].card.number or
+// **.cvv, key_regexp and value_regexp compile Patterns as
+// regular expressions, and secrets matches string values in which Detectors find a secret.
+//
+// Action selects the scrub function: replace (Replacement, or the default replacement),
+// mask (Mask, KeepStart, KeepEnd), remove, hash (salted SHA-256, see HashValue),
+// preserve_length (the first character of Replacement, or of the default replacement)
+// and redact (rewrites only the secrets found by Detectors).
+type RuleConfig struct {
+	Name        string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Match       string   `json:"match" yaml:"match"`
+	Patterns    []string `json:"patterns,omitempty" yaml:"patterns,omitempty"`
+	Detectors   []string `json:"detectors,omitempty" yaml:"detectors,omitempty"`
+	Action      string   `json:"action" yaml:"action"`
+	Replacement string   `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+	Mask        string   `json:"mask,omitempty" yaml:"mask,omitempty"`
+	KeepStart   int      `json:"keep_start,omitempty" yaml:"keep_start,omitempty"`
+	KeepEnd     int      `json:"keep_end,omitempty" yaml:"keep_end,omitempty"`
+	Salt        string   `json:"salt,omitempty" yaml:"salt,omitempty"`
+	Continue    bool     `json:"continue,omitempty" yaml:"continue,omitempty"`
+}
+
+// LoadScrubberConfig reads a config file. Files ending in .json are decoded as JSON and
+// all others as YAML.
+func LoadScrubberConfig(path string) (ScrubberConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ScrubberConfig{}, fmt.Errorf("scrubber config: %w", err)
+	}
+	return parseScrubberConfigFile(path, data)
+}
+
+func parseScrubberConfigFile(path string, data []byte) (ScrubberConfig, error) {
+	format := ConfigFormatYAML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = ConfigFormatJSON
+	}
+	cfg, err := ParseScrubberConfig(data, format)
+	if err != nil {
+		return ScrubberConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseScrubberConfig decodes data in the given format and validates it. Unknown fields
+// are rejected so that typos do not silently disable a rule.
+func ParseScrubberConfig(data []byte, format string) (ScrubberConfig, error) {
+	var cfg ScrubberConfig
+	switch strings.ToLower(format) {
+	case ConfigFormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&cfg); err != nil {
+			return ScrubberConfig{}, fmt.Errorf("scrubber config: decode json: %w", err)
+		}
+	case ConfigFormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return ScrubberConfig{}, fmt.Errorf("scrubber config: decode yaml: %w", err)
+		}
+	default:
+		return ScrubberConfig{}, fmt.Errorf("scrubber config: unsupported format %q", format)
+	}
+	if err := cfg.Validate(); err != nil {
+		return ScrubberConfig{}, err
+	}
+	return cfg, nil
+}
+
+// Validate reports every problem in the config at once, each prefixed with the rule it
+// belongs to.
+func (c ScrubberConfig) Validate() error {
+	_, err := c.build()
+	return err
+}
+
+// NewScrubberFromConfig builds a Scrubber from cfg. opts are applied after the config,
+// e.g. to add rules that can only be written in Go.
+func NewScrubberFromConfig(cfg ScrubberConfig, opts ...ScrubberOption) (*Scrubber, error) {
+	configOpts, err := cfg.build()
+	if err != nil {
+		return nil, err
+	}
+	return NewScrubber(append(configOpts, opts...)...), nil
+}
+
+// LoadScrubber reads the config file at path and builds a Scrubber from it.
+func LoadScrubber(path string, opts ...ScrubberOption) (*Scrubber, error) {
+	cfg, err := LoadScrubberConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewScrubberFromConfig(cfg, opts...)
+}
+
+func (c ScrubberConfig) build() ([]ScrubberOption, error) {
+	var problems []error
+	rules := make([]Rule, 0, len(c.Rules))
+	for i, rc := range c.Rules {
+		rule, errs := rc.build(c.DefaultReplacement)
+		label := fmt.Sprintf("rules[%d]", i)
+		if rc.Name != "" {
+			label += fmt.Sprintf(" (%q)", rc.Name)
+		} else {
+			rule.Name = label
+		}
+		for _, err := range errs {
+			problems = append(problems, fmt.Errorf("%s: %w", label, err))
+		}
+		rules = append(rules, rule)
+	}
+
+	detectors, err := lookupDetectors(c.Detectors)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("detectors: %w", err))
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("scrubber config: %w", errors.Join(problems...))
+	}
+
+	opts := []ScrubberOption{WithDefaultReplacement(c.DefaultReplacement), WithRules(rules...)}
+	if len(detectors) > 0 {
+		opts = append(opts, WithDetectors(detectors...))
+	}
+	if c.DisableDefaultRules {
+		opts = append(opts, WithoutDefaultRules())
+	}
+	return opts, nil
+}
+
+// build falls back to defaultReplacement when the rule has no Replacement. An empty one
+// leaves replace with "[REDACTED]" and preserve_length with "*".
+func (rc RuleConfig) build(defaultReplacement string) (Rule, []error) {
+	var errs []error
+	rule := Rule{Name: rc.Name, Continue: rc.Continue}
+
+	patterns := make([]string, 0, len(rc.Patterns))
+	for _, p := range rc.Patterns {
+		if strings.TrimSpace(p) != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	detectors, err := lookupDetectors(rc.Detectors)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("detectors: %w", err))
+	}
+
+	match := strings.ToLower(strings.TrimSpace(rc.Match))
+	switch match {
+	case "":
+		errs = append(errs, fmt.Errorf("match is required (one of %s)", strings.Join(matchKinds, ", ")))
+	case MatchKindSecrets:
+		rule.Match = MatchSecrets(detectors...)
+	case MatchKindKey, MatchKindKeyPrefix, MatchKindKeyContains, MatchKindPath, MatchKindKeyRegexp, MatchKindValueRegexp:
+		if len(patterns) == 0 {
+			errs = append(errs, fmt.Errorf("match %q needs at least one pattern", match))
+			break
+		}
+		matcher, err := patternMatcher(match, patterns)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		rule.Match = matcher
+	default:
+		errs = append(errs, fmt.Errorf("unknown match %q (want one of %s)", rc.Match, strings.Join(matchKinds, ", ")))
+	}
+
+	replacement := rc.Replacement
+	if replacement == "" {
+		replacement = defaultReplacement
+	}
+	action := strings.ToLower(strings.TrimSpace(rc.Action))
+	switch action {
+	case "":
+		errs = append(errs, fmt.Errorf("action is required (one of %s)", strings.Join(actions, ", ")))
+	case ActionReplace:
+		if replacement == "" {
+			replacement = "[REDACTED]"
+		}
+		rule.Apply = ReplaceWith(replacement)
+	case ActionMask:
+		var mask rune
+		if rc.Mask != "" {
+			if utf8.RuneCountInString(rc.Mask) != 1 {
+				errs = append(errs, fmt.Errorf("mask must be a single character, got %q", rc.Mask))
+			}
+			mask, _ = utf8.DecodeRuneInString(rc.Mask)
+		}
+		if rc.KeepStart < 0 || rc.KeepEnd < 0 {
+			errs = append(errs, fmt.Errorf("keep_start and keep_end must not be negative"))
+		}
+		rule.Apply = MaskString(mask, rc.KeepStart, rc.KeepEnd)
+	case ActionRemove:
+		rule.Apply = RemoveValue()
+	case ActionHash:
+		rule.Apply = HashValue(rc.Salt)
+	case ActionPreserveLength:
+		rule.Apply = PreserveLengthReplacement(replacement)
+	case ActionRedact:
+		rule.Apply = RedactSecrets(detectors...)
+	default:
+		errs = append(errs, fmt.Errorf("unknown action %q (want one of %s)", rc.Action, strings.Join(actions, ", ")))
+	}
+	return rule, errs
+}
+
+func patternMatcher(kind string, patterns []string) (FieldMatcher, error) {
+	switch kind {
+	case MatchKindKey:
+		return MatchKeyInsensitive(patterns...), nil
+	case MatchKindKeyPrefix:
+		return MatchKeyPrefixInsensitive(patterns...), nil
+	case MatchKindKeyContains:
+		return MatchKeyContainsInsensitive(patterns...), nil
+	case MatchKindPath:
+		parsed := make([]PathPattern, 0, len(patterns))
+		for _, p := range patterns {
+			pattern, err := ParsePathPattern(p)
+			if err != nil {
+				return nil, err
+			}
+			parsed = append(parsed, pattern)
+		}
+		return MatchPaths(parsed...), nil
+	}
+
+	matchers := make([]FieldMatcher, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", p, err)
+		}
+		if kind == MatchKindKeyRegexp {
+			matchers = append(matchers, MatchKeyRegexp(re))
+		} else {
+			matchers = append(matchers, MatchValueRegexp(re))
+		}
+	}
+	return MatchAny(matchers...), nil
+}
+
+// lookupDetectors resolves built-in detector names. No names, or "all", select every
+// built-in detector.
+func lookupDetectors(names []string) ([]Detector, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	builtin := DefaultDetectors()
+	byName := make(map[string]Detector, len(builtin))
+	known := make([]string, 0, len(builtin))
+	for _, d := range builtin {
+		byName[d.Name] = d
+		known = append(known, d.Name)
+	}
+
+	var detectors []Detector
+	var unknown []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			return builtin, nil
+		}
+		d, ok := byName[name]
+		if !ok {
+			unknown = append(unknown, fmt.Sprintf("%q", name))
+			continue
+		}
+		detectors = append(detectors, d)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown detector %s (want all or one of %s)", strings.Join(unknown, ", "), strings.Join(known, ", "))
+	}
+	return detectors, nil
+}
+
+// HashValue replaces a value with "sha256:" and the hex SHA-256 of salt followed by the
+// value, so equal values stay correlatable without being readable.
+func HashValue(salt string) ScrubFunc {
+	return func(ctx FieldContext) any {
+		if ctx.Value == nil {
+			return nil
+		}
+		sum := sha256.Sum256([]byte(salt + fmt.Sprint(ctx.Value)))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+}
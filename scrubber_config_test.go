@@ -0,0 +1,201 @@
+package recorder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testScrubberYAML = `
+default_replacement: "<hidden>"
+detectors: [jwt]
+rules:
+  - name: card-number
+    match: path
+    patterns: [payment.card.number]
+    action: mask
+    keep_end: 4
+  - name: customer-email
+    match: key
+    patterns: [email]
+    action: hash
+    salt: pepper
+  - name: internal
+    match: key_prefix
+    patterns: [x-internal-]
+    action: remove
+`
+
+func TestParseScrubberConfigYAML(t *testing.T) {
+	cfg, err := ParseScrubberConfig([]byte(testScrubberYAML), ConfigFormatYAML)
+	if err != nil {
+		t.Fatalf("ParseScrubberConfig returned error: %v", err)
+	}
+	scrubber, err := NewScrubberFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewScrubberFromConfig returned error: %v", err)
+	}
+
+	result := scrubber.ScrubMap(map[string]any{
+		"payment":        map[string]any{"card": map[string]any{"number": "4111111111111111"}},
+		"email":          "jane@example.com",
+		"x-internal-id":  "42",
+		"password":       "hunter2",
+		"note":           "token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig",
+		"untouched_name": "Jane",
+	})
+
+	card := result["payment"].(map[string]any)["card"].(map[string]any)["number"]
+	if card != "************1111" {
+		t.Fatalf("expected masked card, got %v", card)
+	}
+	if email, _ := result["email"].(string); !strings.HasPrefix(email, "sha256:") || len(email) != len("sha256:")+64 {
+		t.Fatalf("expected hashed email, got %v", result["email"])
+	}
+	if result["x-internal-id"] != nil {
+		t.Fatalf("expected removed value, got %v", result["x-internal-id"])
+	}
+	if result["password"] != "<hidden>" {
+		t.Fatalf("expected default rule with configured replacement, got %v", result["password"])
+	}
+	if result["note"] != "token [REDACTED:jwt]" {
+		t.Fatalf("expected configured detector to run, got %v", result["note"])
+	}
+	if result["untouched_name"] != "Jane" {
+		t.Fatalf("expected untouched value, got %v", result["untouched_name"])
+	}
+}
+
+func TestParseScrubberConfigJSON(t *testing.T) {
+	data := `{"disable_default_rules": true, "rules": [
+		{"match": "value_regexp", "patterns": ["^\\d{6}$"], "action": "replace", "replacement": "######", "continue": true},
+		{"match": "key", "patterns": ["otp"], "action": "preserve_length", "replacement": "x"}
+	]}`
+	cfg, err := ParseScrubberConfig([]byte(data), ConfigFormatJSON)
+	if err != nil {
+		t.Fatalf("ParseScrubberConfig returned error: %v", err)
+	}
+	scrubber, err := NewScrubberFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewScrubberFromConfig returned error: %v", err)
+	}
+
+	result := scrubber.ScrubMap(map[string]any{"otp": "123456", "code": "654321", "password": "kept"})
+	if result["otp"] != "xxxxxx" {
+		t.Fatalf("expected continued rule to apply, got %v", result["otp"])
+	}
+	if result["code"] != "######" {
+		t.Fatalf("expected regexp rule to apply, got %v", result["code"])
+	}
+	if result["password"] != "kept" {
+		t.Fatalf("expected default rules to be disabled, got %v", result["password"])
+	}
+}
+
+func TestPreserveLengthUsesDefaultReplacement(t *testing.T) {
+	data := `{"default_replacement": "#", "disable_default_rules": true, "rules": [
+		{"match": "key", "patterns": ["otp"], "action": "preserve_length"}
+	]}`
+	cfg, err := ParseScrubberConfig([]byte(data), ConfigFormatJSON)
+	if err != nil {
+		t.Fatalf("ParseScrubberConfig returned error: %v", err)
+	}
+	scrubber, err := NewScrubberFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewScrubberFromConfig returned error: %v", err)
+	}
+
+	if result := scrubber.ScrubMap(map[string]any{"otp": "123456"}); result["otp"] != "######" {
+		t.Fatalf("expected the default replacement to be used, got %v", result["otp"])
+	}
+
+	cfg.DefaultReplacement = ""
+	if scrubber, err = NewScrubberFromConfig(cfg); err != nil {
+		t.Fatalf("NewScrubberFromConfig returned error: %v", err)
+	}
+	if result := scrubber.ScrubMap(map[string]any{"otp": "123456"}); result["otp"] != "******" {
+		t.Fatalf("expected an unset default replacement to mask with *, got %v", result["otp"])
+	}
+}
+
+func TestParseScrubberConfigValidation(t *testing.T) {
+	data := `
+detectors: [jwt, passport]
+rules:
+  - name: broken
+    match: keyy
+    action: replace
+  - match: key_regexp
+    patterns: ["("]
+    action: mask
+    mask: "**"
+  - name: no-patterns
+    match: path
+  - match: secrets
+    action: shred
+`
+	_, err := ParseScrubberConfig([]byte(data), ConfigFormatYAML)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		`rules[0] ("broken"): unknown match "keyy"`,
+		`rules[1]: pattern "("`,
+		`rules[1]: mask must be a single character`,
+		`rules[2] ("no-patterns"): match "path" needs at least one pattern`,
+		`rules[2] ("no-patterns"): action is required`,
+		`rules[3]: unknown action "shred"`,
+		`detectors: unknown detector "passport"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestParseScrubberConfigRejectsUnknownFields(t *testing.T) {
+	if _, err := ParseScrubberConfig([]byte("rules:\n  - match: key\n    pattern: [x]\n    action: remove\n"), ConfigFormatYAML); err == nil {
+		t.Fatal("expected unknown yaml field to be rejected")
+	}
+	if _, err := ParseScrubberConfig([]byte(`{"rule": []}`), ConfigFormatJSON); err == nil {
+		t.Fatal("expected unknown json field to be rejected")
+	}
+	if _, err := ParseScrubberConfig(nil, "toml"); err == nil {
+		t.Fatal("expected unsupported format to be rejected")
+	}
+}
+
+func TestLoadScrubber(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scrubber.json")
+	if err := os.WriteFile(path, []byte(`{"rules": [{"match": "key", "patterns": ["pin"], "action": "remove"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	scrubber, err := LoadScrubber(path)
+	if err != nil {
+		t.Fatalf("LoadScrubber returned error: %v", err)
+	}
+	if got := scrubber.ScrubMap(map[string]any{"pin": "1234"}); got["pin"] != nil {
+		t.Fatalf("expected pin to be removed, got %v", got["pin"])
+	}
+
+	if _, err := LoadScrubber(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestHashValueIsStable(t *testing.T) {
+	hash := HashValue("salt")
+	a := hash(FieldContext{Value: "value"})
+	b := hash(FieldContext{Value: "value"})
+	c := HashValue("other")(FieldContext{Value: "value"})
+	if a != b {
+		t.Fatalf("expected equal hashes, got %v and %v", a, b)
+	}
+	if a == c {
+		t.Fatal("expected salt to change the hash")
+	}
+	if hash(FieldContext{}) != nil {
+		t.Fatal("expected nil to stay nil")
+	}
+}
@@ -3,6 +3,8 @@ package recorder
 import (
 	"encoding/json"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -184,3 +186,31 @@ func TestScrubber_ScrubStringMap(t *testing.T) {
 		t.Fatalf("expected other key to remain, got %q", result["other"])
 	}
 }
+
+func TestScrubber_SliceElementsGetIndexPaths(t *testing.T) {
+	t.Parallel()
+
+	var paths []string
+	scrub := NewScrubber(
+		WithoutDefaultRules(),
+		WithRules(
+			NewRule("record", func(ctx FieldContext) bool {
+				paths = append(paths, strings.Join(ctx.Path, "."))
+				return false
+			}, RemoveValue()),
+			NewRule("second-tag", MatchPathInsensitive("tags.[1]"), ReplaceWith("***")),
+		),
+	)
+	result := scrub.ScrubMap(map[string]any{
+		"tags": []any{"public", "secret", map[string]any{"k": "v"}},
+	})
+
+	if got := result["tags"].([]any); got[0] != "public" || got[1] != "***" {
+		t.Fatalf("expected second element to be replaced, got %v", got)
+	}
+	for _, want := range []string{"tags.[0]", "tags.[1]", "tags.[2]", "tags.[2].k"} {
+		if !slices.Contains(paths, want) {
+			t.Errorf("expected rules to see path %q, got %v", want, paths)
+		}
+	}
+}
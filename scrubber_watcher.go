@@ -0,0 +1,207 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type ScrubberWatcherOption func(*ScrubberWatcher)
+
+// ScrubberWatcher keeps a Scrubber in sync with a config file. The Scrubber it returns is
+// updated in place, so recorders configured with WithScrubber pick up new rules without
+// being rebuilt.
+type ScrubberWatcher struct {
+	path     string
+	interval time.Duration
+	opts     []ScrubberOption
+	onReload func()
+	onError  func(error)
+	scrubber *Scrubber
+
+	stateMu sync.Mutex
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// WatchScrubberConfig loads the config file at path and returns a watcher for it. The
+// initial load must succeed; later invalid versions are reported to the error handler
+// and the previous rules stay active.
+func WatchScrubberConfig(path string, opts ...ScrubberWatcherOption) (*ScrubberWatcher, error) {
+	w := &ScrubberWatcher{
+		path:     path,
+		interval: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(w)
+		}
+	}
+	if w.interval <= 0 {
+		return nil, fmt.Errorf("scrubber watcher: interval must be positive")
+	}
+	scrubber, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	w.scrubber = scrubber
+	return w, nil
+}
+
+// WithWatchInterval sets how often the file is checked for changes. Defaults to 5s.
+func WithWatchInterval(interval time.Duration) ScrubberWatcherOption {
+	return func(w *ScrubberWatcher) {
+		w.interval = interval
+	}
+}
+
+// WithWatchScrubberOptions adds options applied after the config on every load.
+func WithWatchScrubberOptions(opts ...ScrubberOption) ScrubberWatcherOption {
+	return func(w *ScrubberWatcher) {
+		w.opts = append(w.opts, opts...)
+	}
+}
+
+// WithWatchReloadHandler is called after new rules have been applied.
+func WithWatchReloadHandler(fn func()) ScrubberWatcherOption {
+	return func(w *ScrubberWatcher) {
+		w.onReload = fn
+	}
+}
+
+// WithWatchErrorHandler is called when the file cannot be read or holds an invalid config.
+func WithWatchErrorHandler(fn func(error)) ScrubberWatcherOption {
+	return func(w *ScrubberWatcher) {
+		w.onError = fn
+	}
+}
+
+// Scrubber returns the live scrubber.
+func (w *ScrubberWatcher) Scrubber() *Scrubber {
+	return w.scrubber
+}
+
+// Reload reads the file and applies it if its content changed. On error the current
+// rules are kept.
+func (w *ScrubberWatcher) Reload() error {
+	scrubber, err := w.load()
+	if err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+		return err
+	}
+	if scrubber == nil {
+		return nil
+	}
+	w.scrubber.replaceRules(scrubber)
+	if w.onReload != nil {
+		w.onReload()
+	}
+	return nil
+}
+
+// Start polls the file in the background until Stop is called or ctx is done. Calling
+// Start on a running watcher is a no-op.
+func (w *ScrubberWatcher) Start(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func(done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if w.modified() {
+					_ = w.Reload()
+				}
+			}
+		}
+	}(w.done)
+}
+
+// Stop halts the background polling.
+func (w *ScrubberWatcher) Stop() {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// modified reports whether the file's size or modification time changed since the last
+// load. Errors are left for Reload to report.
+func (w *ScrubberWatcher) modified() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return true
+	}
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+}
+
+// load builds a scrubber from the file. It returns nil without an error when the content
+// is unchanged since the last load. The file's state is recorded even when the config is
+// invalid, so a broken file is reported once rather than on every tick.
+func (w *ScrubberWatcher) load() (*Scrubber, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return nil, fmt.Errorf("scrubber config: %w", err)
+	}
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, fmt.Errorf("scrubber config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+
+	w.stateMu.Lock()
+	unchanged := w.scrubber != nil && bytes.Equal(sum[:], w.sum[:])
+	w.modTime, w.size, w.sum = info.ModTime(), info.Size(), sum
+	w.stateMu.Unlock()
+	if unchanged {
+		return nil, nil
+	}
+
+	cfg, err := parseScrubberConfigFile(w.path, data)
+	if err != nil {
+		return nil, err
+	}
+	return NewScrubberFromConfig(cfg, w.opts...)
+}
+
+// replaceRules swaps in the rules and detectors of other.
+func (s *Scrubber) replaceRules(other *Scrubber) {
+	other.mu.RLock()
+	rules, detectors := other.rules, other.detectors
+	other.mu.RUnlock()
+
+	s.mu.Lock()
+	s.rules, s.detectors = rules, detectors
+	s.mu.Unlock()
+}
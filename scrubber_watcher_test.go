@@ -0,0 +1,89 @@
+package recorder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeScrubberConfig(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScrubberWatcherReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scrubber.yaml")
+	writeScrubberConfig(t, path, "rules:\n  - match: key\n    patterns: [pin]\n    action: remove\n")
+
+	reloaded := make(chan struct{}, 1)
+	errs := make(chan error, 1)
+	watcher, err := WatchScrubberConfig(path,
+		WithWatchInterval(5*time.Millisecond),
+		WithWatchReloadHandler(func() { reloaded <- struct{}{} }),
+		WithWatchErrorHandler(func(err error) { errs <- err }),
+	)
+	if err != nil {
+		t.Fatalf("WatchScrubberConfig returned error: %v", err)
+	}
+	scrubber := watcher.Scrubber()
+	if got := scrubber.ScrubMap(map[string]any{"pin": "1", "cvv": "2"}); got["pin"] != nil || got["cvv"] != "2" {
+		t.Fatalf("unexpected initial result: %v", got)
+	}
+
+	watcher.Start(context.Background())
+	defer watcher.Stop()
+
+	writeScrubberConfig(t, path, "rules:\n  - match: key\n    patterns: [cvv]\n    action: remove\n")
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("expected config to be reloaded")
+	}
+	if got := scrubber.ScrubMap(map[string]any{"pin": "1", "cvv": "2"}); got["pin"] != "1" || got["cvv"] != nil {
+		t.Fatalf("expected reloaded rules on the same scrubber, got %v", got)
+	}
+
+	writeScrubberConfig(t, path, "rules:\n  - match: nope\n    action: remove\n")
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Fatal("expected a validation error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected invalid config to be reported")
+	}
+	if got := scrubber.ScrubMap(map[string]any{"cvv": "2"}); got["cvv"] != nil {
+		t.Fatalf("expected previous rules to stay active, got %v", got)
+	}
+}
+
+func TestScrubberWatcherRequiresValidInitialConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scrubber.yaml")
+	writeScrubberConfig(t, path, "rules:\n  - match: key\n")
+	if _, err := WatchScrubberConfig(path); err == nil {
+		t.Fatal("expected error for invalid initial config")
+	}
+	if _, err := WatchScrubberConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestScrubberWatcherReloadSkipsUnchangedContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scrubber.yaml")
+	writeScrubberConfig(t, path, "rules: []\n")
+	reloads := 0
+	watcher, err := WatchScrubberConfig(path, WithWatchReloadHandler(func() { reloads++ }))
+	if err != nil {
+		t.Fatalf("WatchScrubberConfig returned error: %v", err)
+	}
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("Reload returned error: %v", err)
+	}
+	if reloads != 0 {
+		t.Fatalf("expected unchanged content to be skipped, got %d reloads", reloads)
+	}
+}
@@ -0,0 +1,92 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+)
+
+// ContentTypeTag is the tag under which the content type of value records is stored, so
+// that every backend persisting tags keeps it.
+const ContentTypeTag = "content_type"
+
+// Serializer converts Go values to and from record payloads.
+type Serializer interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type jsonSerializer struct{}
+
+// JSONSerializer encodes values with encoding/json. It is the default serializer.
+func JSONSerializer() Serializer { return jsonSerializer{} }
+
+func (jsonSerializer) ContentType() string { return "application/json" }
+
+func (jsonSerializer) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonSerializer) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type xmlSerializer struct{}
+
+// XMLSerializer encodes values with encoding/xml.
+func XMLSerializer() Serializer { return xmlSerializer{} }
+
+func (xmlSerializer) ContentType() string { return "application/xml" }
+
+func (xmlSerializer) Marshal(v any) ([]byte, error) { return xml.Marshal(v) }
+
+func (xmlSerializer) Unmarshal(data []byte, v any) error { return xml.Unmarshal(data, v) }
+
+// WithSerializer sets the serializer used by RecordValue and the Get*Into readers.
+func WithSerializer(serializer Serializer) RecorderOption {
+	return func(o *recorderOptions) {
+		o.serializer = serializer
+	}
+}
+
+// ValueScrubFunc sanitizes a Go value before it is serialized. It must not modify value
+// in place.
+type ValueScrubFunc func(recordType RecordType, value any) (any, error)
+
+// WithValueScrubber sets the scrubber applied to values passed to RecordValue. When set,
+// the payload scrubber is not applied to the serialized value again.
+func WithValueScrubber(fn ValueScrubFunc) RecorderOption {
+	return func(o *recorderOptions) {
+		if fn == nil {
+			o.valueScrubber = nil
+			return
+		}
+		o.valueScrubber = func(recordType RecordType, value any) (any, *ScrubReport, error) {
+			value, err := fn(recordType, value)
+			return value, nil, err
+		}
+	}
+}
+
+// RecordRequestValue serializes value with the recorder's serializer and records it as a
+// request.
+func RecordRequestValue[T any](ctx context.Context, r Recorder, primaryID *string, requestID string, value T, tags map[string]string) error {
+	return r.RecordValue(ctx, RecordTypeRequest, primaryID, requestID, value, tags)
+}
+
+// RecordResponseValue serializes value with the recorder's serializer and records it as a
+// response.
+func RecordResponseValue[T any](ctx context.Context, r Recorder, primaryID *string, requestID string, value T, tags map[string]string) error {
+	return r.RecordValue(ctx, RecordTypeResponse, primaryID, requestID, value, tags)
+}
+
+// GetRequestValue loads a request and decodes it into a new T.
+func GetRequestValue[T any](ctx context.Context, r Recorder, requestID string) (T, error) {
+	var value T
+	err := r.GetRequestInto(ctx, requestID, &value)
+	return value, err
+}
+
+// GetResponseValue loads a response and decodes it into a new T.
+func GetResponseValue[T any](ctx context.Context, r Recorder, requestID string) (T, error) {
+	var value T
+	err := r.GetResponseInto(ctx, requestID, &value)
+	return value, err
+}
@@ -0,0 +1,141 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type payment struct {
+	XMLName  xml.Name `json:"-" xml:"payment"`
+	ID       string   `json:"id" xml:"id"`
+	Amount   int      `json:"amount" xml:"amount"`
+	Password string   `json:"password" xml:"password"`
+}
+
+type memoryStorage struct {
+	records map[RecordType]map[string]Record
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{records: make(map[RecordType]map[string]Record)}
+}
+
+func (s *memoryStorage) Save(_ context.Context, record Record) error {
+	if s.records[record.Type] == nil {
+		s.records[record.Type] = make(map[string]Record)
+	}
+	s.records[record.Type][record.RequestID] = record
+	return nil
+}
+
+func (s *memoryStorage) Load(_ context.Context, recordType RecordType, requestID string) ([]byte, error) {
+	record, ok := s.records[recordType][requestID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return record.Payload, nil
+}
+
+func (s *memoryStorage) FindByTag(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func TestRecordValueRoundTripJSON(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	rec := New(storage, WithScrubber(NewScrubber()))
+
+	in := payment{ID: "p-1", Amount: 100, Password: "hunter2"}
+	if err := RecordRequestValue(ctx, rec, nil, "req", in, map[string]string{"endpoint": "charge"}); err != nil {
+		t.Fatalf("RecordRequestValue returned error: %v", err)
+	}
+	if in.Password != "hunter2" {
+		t.Fatal("expected the caller's value to be left untouched")
+	}
+
+	stored := storage.records[RecordTypeRequest]["req"]
+	if stored.ContentType != "application/json" || stored.Tags[ContentTypeTag] != "application/json" || stored.Tags["endpoint"] != "charge" {
+		t.Fatalf("unexpected stored record: %+v", stored)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(stored.Payload, &raw); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if raw["password"] != "[REDACTED]" || raw["amount"] != float64(100) {
+		t.Fatalf("unexpected stored payload: %s", stored.Payload)
+	}
+
+	out, err := GetRequestValue[payment](ctx, rec, "req")
+	if err != nil {
+		t.Fatalf("GetRequestValue returned error: %v", err)
+	}
+	if out.ID != "p-1" || out.Amount != 100 || out.Password != "[REDACTED]" {
+		t.Fatalf("unexpected decoded value: %+v", out)
+	}
+}
+
+func TestRecordValueXMLSerializer(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	rec := New(storage, WithSerializer(XMLSerializer()), WithScrubber(NewScrubber()))
+
+	if err := RecordResponseValue(ctx, rec, nil, "req", &payment{ID: "p-1", Password: "hunter2"}, nil); err != nil {
+		t.Fatalf("RecordResponseValue returned error: %v", err)
+	}
+	stored := storage.records[RecordTypeResponse]["req"]
+	if stored.ContentType != "application/xml" || !strings.Contains(string(stored.Payload), "<password>[REDACTED]</password>") {
+		t.Fatalf("unexpected stored record: %s (%s)", stored.Payload, stored.ContentType)
+	}
+
+	var out payment
+	if err := rec.GetResponseInto(ctx, "req", &out); err != nil {
+		t.Fatalf("GetResponseInto returned error: %v", err)
+	}
+	if out.ID != "p-1" {
+		t.Fatalf("unexpected decoded value: %+v", out)
+	}
+}
+
+func TestRecordValueErrors(t *testing.T) {
+	ctx := context.Background()
+	rec := New(newMemoryStorage())
+
+	if err := rec.RecordValue(ctx, RecordTypeRequest, nil, "", payment{}, nil); err == nil {
+		t.Fatal("expected error for empty requestID")
+	}
+	if err := rec.RecordValue(ctx, RecordTypeRequest, nil, "req", nil, nil); err == nil {
+		t.Fatal("expected error for nil value")
+	}
+	if err := rec.RecordValue(ctx, RecordTypeRequest, nil, "req", make(chan int), nil); err == nil {
+		t.Fatal("expected serialization error")
+	}
+	var out payment
+	if err := rec.GetRequestInto(ctx, "missing", &out); err == nil {
+		t.Fatal("expected load error")
+	}
+
+	valueErr := errors.New("value scrub failed")
+	rec = New(newMemoryStorage(), WithValueScrubber(func(RecordType, any) (any, error) { return nil, valueErr }))
+	if err := rec.RecordValue(ctx, RecordTypeRequest, nil, "req", payment{}, nil); !errors.Is(err, valueErr) {
+		t.Fatalf("expected value scrubber error, got %v", err)
+	}
+}
+
+func TestPayloadScrubberStillAppliesWithoutValueScrubber(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	rec := New(storage, WithPayloadScrubber(func(_ RecordType, payload []byte) ([]byte, error) {
+		return []byte(`"scrubbed"`), nil
+	}))
+
+	if err := rec.RecordValue(ctx, RecordTypeRequest, nil, "req", payment{ID: "p-1"}, nil); err != nil {
+		t.Fatalf("RecordValue returned error: %v", err)
+	}
+	if got := string(storage.records[RecordTypeRequest]["req"].Payload); got != `"scrubbed"` {
+		t.Fatalf("expected payload scrubber to run, got %s", got)
+	}
+}
@@ -0,0 +1,52 @@
+// Package serializers provides recorder.Serializer implementations that need third-party
+// encoders. JSON and XML serializers live in the recorder package itself.
+package serializers
+
+import (
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/stremovskyy/recorder"
+)
+
+type protobufSerializer struct {
+	marshal   proto.MarshalOptions
+	unmarshal proto.UnmarshalOptions
+}
+
+// Protobuf encodes proto.Message values in the protobuf wire format. Values that are not
+// messages are rejected.
+func Protobuf() recorder.Serializer {
+	return protobufSerializer{marshal: proto.MarshalOptions{Deterministic: true}}
+}
+
+func (protobufSerializer) ContentType() string { return "application/x-protobuf" }
+
+func (s protobufSerializer) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf: %T does not implement proto.Message", v)
+	}
+	return s.marshal.Marshal(msg)
+}
+
+func (s protobufSerializer) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf: %T does not implement proto.Message", v)
+	}
+	return s.unmarshal.Unmarshal(data, msg)
+}
+
+type msgpackSerializer struct{}
+
+// Msgpack encodes values with MessagePack.
+func Msgpack() recorder.Serializer { return msgpackSerializer{} }
+
+func (msgpackSerializer) ContentType() string { return "application/msgpack" }
+
+func (msgpackSerializer) Marshal(v any) ([]byte, error) { return msgpack.Marshal(v) }
+
+func (msgpackSerializer) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
@@ -0,0 +1,76 @@
+package serializers
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/stremovskyy/recorder"
+)
+
+type captureStorage struct {
+	saved recorder.Record
+}
+
+func (s *captureStorage) Save(_ context.Context, record recorder.Record) error {
+	s.saved = record
+	return nil
+}
+
+func (s *captureStorage) Load(context.Context, recorder.RecordType, string) ([]byte, error) {
+	return s.saved.Payload, nil
+}
+
+func (s *captureStorage) FindByTag(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func TestProtobufSerializer(t *testing.T) {
+	ctx := context.Background()
+	storage := &captureStorage{}
+	rec := recorder.New(storage, recorder.WithSerializer(Protobuf()))
+
+	if err := recorder.RecordRequestValue(ctx, rec, nil, "req", wrapperspb.String("hello"), nil); err != nil {
+		t.Fatalf("RecordRequestValue returned error: %v", err)
+	}
+	if storage.saved.ContentType != "application/x-protobuf" {
+		t.Fatalf("unexpected content type: %s", storage.saved.ContentType)
+	}
+
+	out := &wrapperspb.StringValue{}
+	if err := rec.GetRequestInto(ctx, "req", out); err != nil {
+		t.Fatalf("GetRequestInto returned error: %v", err)
+	}
+	if !proto.Equal(out, wrapperspb.String("hello")) {
+		t.Fatalf("unexpected decoded message: %v", out)
+	}
+
+	if _, err := Protobuf().Marshal(struct{}{}); err == nil {
+		t.Fatal("expected error for non-message value")
+	}
+	if err := Protobuf().Unmarshal(nil, &struct{}{}); err == nil {
+		t.Fatal("expected error for non-message destination")
+	}
+}
+
+func TestMsgpackSerializer(t *testing.T) {
+	type payload struct {
+		ID    string `msgpack:"id"`
+		Count int    `msgpack:"count"`
+	}
+
+	s := Msgpack()
+	data, err := s.Marshal(payload{ID: "a", Count: 2})
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	var out payload
+	if err := s.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if out.ID != "a" || out.Count != 2 || s.ContentType() != "application/msgpack" {
+		t.Fatalf("unexpected round trip: %+v", out)
+	}
+}
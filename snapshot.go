@@ -0,0 +1,110 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetricsSnapshot is a point-in-time copy of every series held by a Metrics implementation.
+// Series are sorted by name and then by tags.
+type MetricsSnapshot struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Counters   []CounterSeries   `json:"counters,omitempty"`
+	Gauges     []GaugeSeries     `json:"gauges,omitempty"`
+	Histograms []HistogramSeries `json:"histograms,omitempty"`
+}
+
+type CounterSeries struct {
+	Name  string            `json:"name"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	Value int64             `json:"value"`
+}
+
+type GaugeSeries struct {
+	Name  string            `json:"name"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	Value float64           `json:"value"`
+}
+
+type HistogramSeries struct {
+	Name string            `json:"name"`
+	Tags map[string]string `json:"tags,omitempty"`
+	HistogramSnapshot
+}
+
+type seriesID struct {
+	name string
+	tags map[string]string
+}
+
+func (s *MetricsSnapshot) sort() {
+	sort.Slice(s.Counters, func(i, j int) bool {
+		return seriesLess(s.Counters[i].Name, s.Counters[i].Tags, s.Counters[j].Name, s.Counters[j].Tags)
+	})
+	sort.Slice(s.Gauges, func(i, j int) bool {
+		return seriesLess(s.Gauges[i].Name, s.Gauges[i].Tags, s.Gauges[j].Name, s.Gauges[j].Tags)
+	})
+	sort.Slice(s.Histograms, func(i, j int) bool {
+		return seriesLess(s.Histograms[i].Name, s.Histograms[i].Tags, s.Histograms[j].Name, s.Histograms[j].Tags)
+	})
+}
+
+func seriesLess(nameA string, tagsA map[string]string, nameB string, tagsB map[string]string) bool {
+	if nameA != nameB {
+		return nameA < nameB
+	}
+	return metricKey("", tagsA) < metricKey("", tagsB)
+}
+
+// EncodeSnapshotJSON writes the snapshot as a single JSON document.
+func EncodeSnapshotJSON(w io.Writer, snapshot MetricsSnapshot) error {
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// EncodeSnapshotOpenMetrics writes the snapshot in the OpenMetrics text format. Histogram
+// series are exported as summaries with 0.5/0.9/0.99 quantiles.
+func EncodeSnapshotOpenMetrics(w io.Writer, snapshot MetricsSnapshot) error {
+	bw := bufio.NewWriter(w)
+
+	lastFamily := ""
+	for _, c := range snapshot.Counters {
+		family := strings.TrimSuffix(sanitizeMetricName(c.Name), "_total")
+		if family != lastFamily {
+			writeTypeLine(bw, family, "counter")
+			lastFamily = family
+		}
+		writeSample(bw, family+"_total", c.Tags, strconv.FormatInt(c.Value, 10))
+	}
+
+	lastFamily = ""
+	for _, g := range snapshot.Gauges {
+		family := sanitizeMetricName(g.Name)
+		if family != lastFamily {
+			writeTypeLine(bw, family, "gauge")
+			lastFamily = family
+		}
+		writeSample(bw, family, g.Tags, formatFloat(g.Value))
+	}
+
+	lastFamily = ""
+	for _, h := range snapshot.Histograms {
+		family := sanitizeMetricName(h.Name)
+		if family != lastFamily {
+			writeTypeLine(bw, family, "summary")
+			lastFamily = family
+		}
+		writeSample(bw, family, withLabel(h.Tags, "quantile", "0.5"), formatFloat(h.P50))
+		writeSample(bw, family, withLabel(h.Tags, "quantile", "0.9"), formatFloat(h.P90))
+		writeSample(bw, family, withLabel(h.Tags, "quantile", "0.99"), formatFloat(h.P99))
+		writeSample(bw, family+"_sum", h.Tags, formatFloat(h.Sum))
+		writeSample(bw, family+"_count", h.Tags, strconv.FormatUint(h.Count, 10))
+	}
+
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
@@ -0,0 +1,105 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsSnapshotKeepsNameAndTagsApart(t *testing.T) {
+	m := NewMetrics()
+	m.IncrementCounter("redis.get_data.errors", map[string]string{"error": "get_failed", "prefix": "request"})
+	m.IncrementCounter("redis.get_data.errors", map[string]string{"prefix": "request", "error": "get_failed"})
+	m.IncrementCounter("a.first", nil)
+	m.SetGauge("redis.pool.idle_conns", 2, nil)
+	m.RecordTiming("redis.get_data.duration", 20*time.Millisecond, map[string]string{"prefix": "request"})
+
+	snapshot := m.Snapshot()
+	if snapshot.Timestamp.IsZero() {
+		t.Fatal("expected snapshot timestamp")
+	}
+
+	wantCounters := []CounterSeries{
+		{Name: "a.first", Value: 1},
+		{Name: "redis.get_data.errors", Tags: map[string]string{"error": "get_failed", "prefix": "request"}, Value: 2},
+	}
+	if !reflect.DeepEqual(snapshot.Counters, wantCounters) {
+		t.Fatalf("unexpected counters: %+v", snapshot.Counters)
+	}
+	if len(snapshot.Gauges) != 1 || snapshot.Gauges[0].Value != 2 {
+		t.Fatalf("unexpected gauges: %+v", snapshot.Gauges)
+	}
+	if len(snapshot.Histograms) != 1 || snapshot.Histograms[0].Count != 1 || snapshot.Histograms[0].Tags["prefix"] != "request" {
+		t.Fatalf("unexpected histograms: %+v", snapshot.Histograms)
+	}
+
+	snapshot.Counters[1].Tags["prefix"] = "mutated"
+	if m.Snapshot().Counters[1].Tags["prefix"] != "request" {
+		t.Fatal("expected snapshot tags to be copies")
+	}
+}
+
+func TestEncodeSnapshotJSON(t *testing.T) {
+	m := NewMetrics()
+	m.IncrementCounter("requests", map[string]string{"type": "request"})
+
+	var buf bytes.Buffer
+	if err := EncodeSnapshotJSON(&buf, m.Snapshot()); err != nil {
+		t.Fatalf("EncodeSnapshotJSON returned error: %v", err)
+	}
+
+	var decoded MetricsSnapshot
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to decode snapshot: %v", err)
+	}
+	if len(decoded.Counters) != 1 || decoded.Counters[0].Name != "requests" || decoded.Counters[0].Tags["type"] != "request" {
+		t.Fatalf("unexpected decoded snapshot: %+v", decoded)
+	}
+}
+
+func TestEncodeSnapshotOpenMetrics(t *testing.T) {
+	m := NewMetrics()
+	m.IncrementCounter("redis.record_data.success", map[string]string{"prefix": "request"})
+	m.SetGauge("queue.depth", 3, nil)
+	m.RecordHistogram("payload.size", 10, nil)
+
+	var buf bytes.Buffer
+	if err := EncodeSnapshotOpenMetrics(&buf, m.Snapshot()); err != nil {
+		t.Fatalf("EncodeSnapshotOpenMetrics returned error: %v", err)
+	}
+	out := buf.String()
+
+	for _, line := range []string{
+		"# TYPE redis_record_data_success counter",
+		`redis_record_data_success_total{prefix="request"} 1`,
+		"# TYPE queue_depth gauge",
+		"queue_depth 3",
+		"# TYPE payload_size summary",
+		`payload_size{quantile="0.5"} 10`,
+		"payload_size_count 1",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Fatalf("expected output to contain %q, got:\n%s", line, out)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Fatalf("expected OpenMetrics terminator, got:\n%s", out)
+	}
+}
+
+func TestPrometheusMetricsSnapshot(t *testing.T) {
+	m := NewPrometheusMetrics(WithPrometheusConstLabels(map[string]string{"env": "prod"}))
+	m.IncrementCounter("requests", map[string]string{"type": "request"})
+
+	snapshot := m.Snapshot()
+	if len(snapshot.Counters) != 1 {
+		t.Fatalf("unexpected counters: %+v", snapshot.Counters)
+	}
+	counter := snapshot.Counters[0]
+	if counter.Name != "requests_total" || counter.Tags["type"] != "request" || counter.Tags["env"] != "prod" {
+		t.Fatalf("unexpected counter series: %+v", counter)
+	}
+}
@@ -0,0 +1,365 @@
+package recorder
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrTokenNotFound is returned by Detokenize when the vault has no value for a token.
+	ErrTokenNotFound = errors.New("tokenizer: token not found")
+	// ErrTokenCollision is returned by a TokenStore when a token already maps to a
+	// different value.
+	ErrTokenCollision = errors.New("tokenizer: token collision")
+	// ErrNoTokenVault is returned by Detokenize when the tokenizer has no TokenStore.
+	ErrNoTokenVault = errors.New("tokenizer: no token vault configured")
+)
+
+// TokenKey is an HMAC key. ID is embedded in pseudonyms so tokens made with a retired
+// key can still be told apart; it may only contain letters and digits.
+type TokenKey struct {
+	ID     string
+	Secret []byte
+}
+
+// TokenStore keeps the original values behind tokens for reversible tokenization.
+// Put must return ErrTokenCollision when token already maps to a different value.
+type TokenStore interface {
+	Put(ctx context.Context, token, value string) error
+	Get(ctx context.Context, token string) (string, error)
+}
+
+type TokenizerOption func(*Tokenizer)
+
+// Tokenizer replaces values with deterministic tokens derived from a keyed HMAC, so the
+// same input always maps to the same token and records stay correlatable without
+// exposing the value.
+type Tokenizer struct {
+	active   TokenKey
+	previous []TokenKey
+	prefix   string
+	vault    TokenStore
+	onError  func(error)
+}
+
+var tokenKeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+const minTokenKeySize = 16
+
+// NewTokenizer builds a Tokenizer that issues tokens with active. Tokens made with
+// previous keys are still recognised by Matches and Tokens, which allows rotating keys
+// without losing the ability to find older records.
+func NewTokenizer(active TokenKey, previous []TokenKey, opts ...TokenizerOption) (*Tokenizer, error) {
+	seen := make(map[string]struct{}, len(previous)+1)
+	for _, key := range append([]TokenKey{active}, previous...) {
+		if !tokenKeyIDPattern.MatchString(key.ID) {
+			return nil, fmt.Errorf("tokenizer: key id %q must be non-empty and alphanumeric", key.ID)
+		}
+		if len(key.Secret) < minTokenKeySize {
+			return nil, fmt.Errorf("tokenizer: key %q must be at least %d bytes", key.ID, minTokenKeySize)
+		}
+		if _, dup := seen[key.ID]; dup {
+			return nil, fmt.Errorf("tokenizer: duplicate key id %q", key.ID)
+		}
+		seen[key.ID] = struct{}{}
+	}
+	t := &Tokenizer{
+		active:   active,
+		previous: append([]TokenKey(nil), previous...),
+		prefix:   "tok",
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(t)
+		}
+	}
+	return t, nil
+}
+
+// WithTokenPrefix sets the prefix of pseudonyms. Defaults to "tok".
+func WithTokenPrefix(prefix string) TokenizerOption {
+	return func(t *Tokenizer) {
+		t.prefix = prefix
+	}
+}
+
+// WithTokenVault stores every issued token with its original value in store, so it can
+// be reversed with Detokenize.
+func WithTokenVault(store TokenStore) TokenizerOption {
+	return func(t *Tokenizer) {
+		t.vault = store
+	}
+}
+
+// WithTokenErrorHandler is called when the vault fails to store a token. The token is
+// still returned, so the value is never leaked, but it cannot be reversed.
+func WithTokenErrorHandler(fn func(error)) TokenizerOption {
+	return func(t *Tokenizer) {
+		t.onError = fn
+	}
+}
+
+// Token returns the pseudonym of value under the active key, e.g. "tok_k2_9f86d081884c7d65".
+func (t *Tokenizer) Token(value string) string {
+	token := t.pseudonym(t.active, value)
+	t.store(token, value)
+	return token
+}
+
+// Tokens returns the pseudonym of value under every key, active first, to look up
+// records written before a key rotation. See DigitTokens and EmailTokens for the
+// format-preserving tokens.
+func (t *Tokenizer) Tokens(value string) []string {
+	tokens := []string{t.pseudonym(t.active, value)}
+	for _, key := range t.previous {
+		tokens = append(tokens, t.pseudonym(key, value))
+	}
+	return tokens
+}
+
+// Matches reports whether token is the pseudonym of value under any known key.
+func (t *Tokenizer) Matches(value, token string) bool {
+	for _, candidate := range t.Tokens(value) {
+		if hmac.Equal([]byte(candidate), []byte(token)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Detokenize returns the original value of a token issued with a vault configured.
+func (t *Tokenizer) Detokenize(ctx context.Context, token string) (string, error) {
+	if t.vault == nil {
+		return "", ErrNoTokenVault
+	}
+	return t.vault.Get(ctx, token)
+}
+
+// Pseudonymize is a ScrubFunc replacing values with their Token.
+func (t *Tokenizer) Pseudonymize() ScrubFunc {
+	return func(ctx FieldContext) any {
+		if ctx.Value == nil {
+			return nil
+		}
+		return t.Token(fmt.Sprint(ctx.Value))
+	}
+}
+
+// TokenizeDigits is a format-preserving ScrubFunc that replaces digits with digits
+// derived from the active key, keeping separators and the first keepStart and last
+// keepEnd digits: with keepEnd 4, "4111 1111 1111 1111" becomes something like
+// "7302 9185 4410 1111". Values with no more digits than are kept are replaced entirely.
+//
+// The token embeds no key ID; use DigitTokens to look values up across key rotations.
+// Only the replaced digits are random, so n of them leave 10^n tokens for values sharing
+// the kept digits: with keepStart 6 and keepEnd 4 a 16-digit PAN has 10^6, and collisions
+// become likely after about 1,000 such PANs. With a vault, a value whose token collides
+// gets a Token instead so it stays reversible.
+func (t *Tokenizer) TokenizeDigits(keepStart, keepEnd int) ScrubFunc {
+	keepStart, keepEnd = max(keepStart, 0), max(keepEnd, 0)
+	return func(ctx FieldContext) any {
+		if ctx.Value == nil {
+			return nil
+		}
+		value := fmt.Sprint(ctx.Value)
+		token, ok := t.digitsToken(t.active, value, keepStart, keepEnd)
+		if !ok {
+			return t.Token(value)
+		}
+		return t.storeFormatted(token, value)
+	}
+}
+
+// DigitTokens returns the TokenizeDigits token of value under every key, active first,
+// followed by the Tokens issued in its place for values without digits or on a vault
+// collision.
+func (t *Tokenizer) DigitTokens(value string, keepStart, keepEnd int) []string {
+	keepStart, keepEnd = max(keepStart, 0), max(keepEnd, 0)
+	var tokens []string
+	for _, key := range t.keys() {
+		if token, ok := t.digitsToken(key, value, keepStart, keepEnd); ok {
+			tokens = append(tokens, token)
+		}
+	}
+	return append(tokens, t.Tokens(value)...)
+}
+
+// TokenizeEmail is a format-preserving ScrubFunc that replaces the local part of an email
+// address and keeps its domain: "jane@example.com" becomes something like
+// "u3fa9c1d2e0@example.com". Other values get a Token.
+//
+// The token embeds no key ID; use EmailTokens to look values up across key rotations.
+// The local part carries 40 random bits, so collisions within a domain become likely
+// after about a million addresses. With a vault, a colliding address gets a Token instead.
+func (t *Tokenizer) TokenizeEmail() ScrubFunc {
+	return func(ctx FieldContext) any {
+		if ctx.Value == nil {
+			return nil
+		}
+		value := fmt.Sprint(ctx.Value)
+		token, ok := t.emailToken(t.active, value)
+		if !ok {
+			return t.Token(value)
+		}
+		return t.storeFormatted(token, value)
+	}
+}
+
+// EmailTokens returns the TokenizeEmail token of value under every key, active first,
+// followed by the Tokens issued in its place for other values or on a vault collision.
+func (t *Tokenizer) EmailTokens(value string) []string {
+	var tokens []string
+	for _, key := range t.keys() {
+		if token, ok := t.emailToken(key, value); ok {
+			tokens = append(tokens, token)
+		}
+	}
+	return append(tokens, t.Tokens(value)...)
+}
+
+func (t *Tokenizer) keys() []TokenKey {
+	return append([]TokenKey{t.active}, t.previous...)
+}
+
+// digitsToken reports false when value has no digits.
+func (t *Tokenizer) digitsToken(key TokenKey, value string, keepStart, keepEnd int) (string, bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+	if digits == "" {
+		return "", false
+	}
+	start, end := keepStart, keepEnd
+	if start+end >= len(digits) {
+		start, end = 0, 0
+	}
+	stream := t.digitStream(key, "digits", digits, len(digits))
+
+	var b strings.Builder
+	i := 0
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			b.WriteRune(r)
+			continue
+		}
+		if i < start || i >= len(digits)-end {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte(stream[i])
+		}
+		i++
+	}
+	return b.String(), true
+}
+
+// emailToken reports false when value is not an email address.
+func (t *Tokenizer) emailToken(key TokenKey, value string) (string, bool) {
+	at := strings.LastIndexByte(value, '@')
+	if at <= 0 || at == len(value)-1 {
+		return "", false
+	}
+	mac := t.mac(key, "email", strings.ToLower(value))
+	return "u" + hex.EncodeToString(mac[:5]) + value[at:], true
+}
+
+func (t *Tokenizer) pseudonym(key TokenKey, value string) string {
+	mac := t.mac(key, "token", value)
+	return t.prefix + "_" + key.ID + "_" + hex.EncodeToString(mac[:8])
+}
+
+// mac derives an HMAC of value, separated by kind so the different token formats of the
+// same value cannot be linked.
+func (t *Tokenizer) mac(key TokenKey, kind, value string) []byte {
+	h := hmac.New(sha256.New, key.Secret)
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return h.Sum(nil)
+}
+
+func (t *Tokenizer) digitStream(key TokenKey, kind, value string, n int) []byte {
+	out := make([]byte, 0, n)
+	var counter [4]byte
+	for block := uint32(0); len(out) < n; block++ {
+		binary.BigEndian.PutUint32(counter[:], block)
+		for _, b := range t.mac(key, kind, value+string(counter[:])) {
+			if b >= 250 { // keep the digits uniform
+				continue
+			}
+			out = append(out, '0'+b%10)
+			if len(out) == n {
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (t *Tokenizer) store(token, value string) {
+	t.report(token, t.put(token, value))
+}
+
+// storeFormatted stores a format-preserving token, falling back to Token when it already
+// belongs to another value: the format cannot hold enough randomness to rule that out.
+func (t *Tokenizer) storeFormatted(token, value string) string {
+	err := t.put(token, value)
+	if errors.Is(err, ErrTokenCollision) {
+		return t.Token(value)
+	}
+	t.report(token, err)
+	return token
+}
+
+func (t *Tokenizer) put(token, value string) error {
+	if t.vault == nil || token == value {
+		return nil
+	}
+	return t.vault.Put(context.Background(), token, value)
+}
+
+func (t *Tokenizer) report(token string, err error) {
+	if err != nil && t.onError != nil {
+		t.onError(fmt.Errorf("tokenizer: store token %s: %w", token, err))
+	}
+}
+
+// MemoryTokenStore is an in-process TokenStore, suitable for tests and single instances.
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{values: make(map[string]string)}
+}
+
+func (m *MemoryTokenStore) Put(_ context.Context, token, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.values[token]; ok && existing != value {
+		return ErrTokenCollision
+	}
+	m.values[token] = value
+	return nil
+}
+
+func (m *MemoryTokenStore) Get(_ context.Context, token string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.values[token]
+	if !ok {
+		return "", ErrTokenNotFound
+	}
+	return value, nil
+}
@@ -0,0 +1,197 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func testTokenizer(t *testing.T, opts ...TokenizerOption) *Tokenizer {
+	t.Helper()
+	tok, err := NewTokenizer(TokenKey{ID: "k2", Secret: []byte("0123456789abcdef-two")},
+		[]TokenKey{{ID: "k1", Secret: []byte("0123456789abcdef-one")}}, opts...)
+	if err != nil {
+		t.Fatalf("NewTokenizer returned error: %v", err)
+	}
+	return tok
+}
+
+func TestTokenizerPseudonymizeIsDeterministic(t *testing.T) {
+	tok := testTokenizer(t)
+	scrub := tok.Pseudonymize()
+
+	a := scrub(FieldContext{Value: "customer-42"})
+	b := scrub(FieldContext{Value: "customer-42"})
+	c := scrub(FieldContext{Value: "customer-43"})
+	if a != b {
+		t.Fatalf("expected identical tokens, got %v and %v", a, b)
+	}
+	if a == c {
+		t.Fatal("expected different inputs to get different tokens")
+	}
+	if !regexp.MustCompile(`^tok_k2_[0-9a-f]{16}$`).MatchString(a.(string)) {
+		t.Fatalf("unexpected token format %v", a)
+	}
+	if scrub(FieldContext{}) != nil {
+		t.Fatal("expected nil to stay nil")
+	}
+}
+
+func TestTokenizerKeyRotation(t *testing.T) {
+	old, err := NewTokenizer(TokenKey{ID: "k1", Secret: []byte("0123456789abcdef-one")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken := old.Token("jane@example.com")
+
+	rotated := testTokenizer(t)
+	if rotated.Token("jane@example.com") == oldToken {
+		t.Fatal("expected the active key to issue new tokens")
+	}
+	if !rotated.Matches("jane@example.com", oldToken) {
+		t.Fatal("expected tokens of previous keys to match")
+	}
+	if tokens := rotated.Tokens("jane@example.com"); len(tokens) != 2 || tokens[1] != oldToken {
+		t.Fatalf("expected tokens for every key, got %v", tokens)
+	}
+	if rotated.Matches("john@example.com", oldToken) {
+		t.Fatal("expected a different value not to match")
+	}
+}
+
+func TestTokenizerFormatPreserving(t *testing.T) {
+	tok := testTokenizer(t)
+
+	pan := tok.TokenizeDigits(0, 4)(FieldContext{Value: "4111 1111 1111 1111"}).(string)
+	if !regexp.MustCompile(`^\d{4} \d{4} \d{4} 1111$`).MatchString(pan) || pan == "4111 1111 1111 1111" {
+		t.Fatalf("unexpected tokenized PAN %q", pan)
+	}
+	if again := tok.TokenizeDigits(0, 4)(FieldContext{Value: "4111-1111-1111-1111"}).(string); strings.ReplaceAll(again, "-", " ") != pan {
+		t.Fatalf("expected separators not to change the token, got %q and %q", again, pan)
+	}
+	if short := tok.TokenizeDigits(6, 4)(FieldContext{Value: "1234"}).(string); len(short) != 4 || short == "1234" {
+		t.Fatalf("expected short value to be replaced entirely, got %q", short)
+	}
+
+	email := tok.TokenizeEmail()(FieldContext{Value: "Jane.Doe@Example.com"}).(string)
+	if !regexp.MustCompile(`^u[0-9a-f]{10}@Example\.com$`).MatchString(email) {
+		t.Fatalf("unexpected tokenized email %q", email)
+	}
+	if other := tok.TokenizeEmail()(FieldContext{Value: "jane.doe@example.com"}).(string); other[:11] != email[:11] {
+		t.Fatalf("expected email tokens to ignore case, got %q and %q", other, email)
+	}
+}
+
+func TestTokenizerFormatPreservingKeyRotation(t *testing.T) {
+	old, err := NewTokenizer(TokenKey{ID: "k1", Secret: []byte("0123456789abcdef-one")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPAN := old.TokenizeDigits(6, 4)(FieldContext{Value: "4111111111111111"}).(string)
+	oldEmail := old.TokenizeEmail()(FieldContext{Value: "jane@example.com"}).(string)
+
+	rotated := testTokenizer(t)
+	pans := rotated.DigitTokens("4111111111111111", 6, 4)
+	if len(pans) != 4 || pans[1] != oldPAN {
+		t.Fatalf("expected digit tokens for every key, got %v", pans)
+	}
+	if pans[0] != rotated.TokenizeDigits(6, 4)(FieldContext{Value: "4111111111111111"}) {
+		t.Fatalf("expected the active digit token first, got %v", pans)
+	}
+	emails := rotated.EmailTokens("jane@example.com")
+	if len(emails) != 4 || emails[1] != oldEmail {
+		t.Fatalf("expected email tokens for every key, got %v", emails)
+	}
+	if other := rotated.EmailTokens("not-an-email"); len(other) != 2 || other[0] != rotated.Token("not-an-email") {
+		t.Fatalf("expected plain tokens for a non-email value, got %v", other)
+	}
+}
+
+func TestTokenizerFormatPreservingCollision(t *testing.T) {
+	var reported error
+	store := NewMemoryTokenStore()
+	tok := testTokenizer(t, WithTokenVault(store), WithTokenErrorHandler(func(err error) { reported = err }))
+	ctx := context.Background()
+
+	pan := testTokenizer(t).TokenizeDigits(6, 4)(FieldContext{Value: "4111111111111111"}).(string)
+	email := testTokenizer(t).TokenizeEmail()(FieldContext{Value: "jane@example.com"}).(string)
+	for _, token := range []string{pan, email} {
+		if err := store.Put(ctx, token, "someone else"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		value string
+		scrub ScrubFunc
+	}{
+		{"4111111111111111", tok.TokenizeDigits(6, 4)},
+		{"jane@example.com", tok.TokenizeEmail()},
+	} {
+		token := tc.scrub(FieldContext{Value: tc.value}).(string)
+		if token != tok.Token(tc.value) {
+			t.Fatalf("expected a colliding %q to fall back to Token, got %q", tc.value, token)
+		}
+		if got, err := tok.Detokenize(ctx, token); err != nil || got != tc.value {
+			t.Fatalf("Detokenize(%q) = %q, %v; want %q", token, got, err, tc.value)
+		}
+	}
+	if reported != nil {
+		t.Fatalf("expected handled collisions not to be reported, got %v", reported)
+	}
+}
+
+func TestTokenizerVault(t *testing.T) {
+	var reported error
+	store := NewMemoryTokenStore()
+	tok := testTokenizer(t, WithTokenVault(store), WithTokenErrorHandler(func(err error) { reported = err }))
+
+	scrub := NewScrubber(WithoutDefaultRules(), WithRules(
+		NewRule("email", MatchKeyInsensitive("email"), tok.TokenizeEmail()),
+		NewRule("customer", MatchKeyInsensitive("customer"), tok.Pseudonymize()),
+	))
+	result := scrub.ScrubMap(map[string]any{"email": "jane@example.com", "customer": "42"})
+
+	ctx := context.Background()
+	for token, want := range map[string]string{result["email"].(string): "jane@example.com", result["customer"].(string): "42"} {
+		got, err := tok.Detokenize(ctx, token)
+		if err != nil || got != want {
+			t.Fatalf("Detokenize(%q) = %q, %v; want %q", token, got, err, want)
+		}
+	}
+	if _, err := tok.Detokenize(ctx, "tok_k2_0000000000000000"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound, got %v", err)
+	}
+
+	token := result["customer"].(string)
+	if !errors.Is(store.Put(ctx, token, "other"), ErrTokenCollision) {
+		t.Fatal("expected collision to be reported by the store")
+	}
+	if reported != nil {
+		t.Fatalf("unexpected vault error: %v", reported)
+	}
+
+	if _, err := testTokenizer(t).Detokenize(ctx, token); !errors.Is(err, ErrNoTokenVault) {
+		t.Fatalf("expected ErrNoTokenVault, got %v", err)
+	}
+}
+
+func TestNewTokenizerValidatesKeys(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	cases := []struct {
+		active   TokenKey
+		previous []TokenKey
+	}{
+		{TokenKey{ID: "", Secret: secret}, nil},
+		{TokenKey{ID: "k_1", Secret: secret}, nil},
+		{TokenKey{ID: "k1", Secret: []byte("short")}, nil},
+		{TokenKey{ID: "k1", Secret: secret}, []TokenKey{{ID: "k1", Secret: secret}}},
+	}
+	for _, tc := range cases {
+		if _, err := NewTokenizer(tc.active, tc.previous); err == nil {
+			t.Errorf("expected keys %+v / %+v to be rejected", tc.active, tc.previous)
+		}
+	}
+}